@@ -0,0 +1,466 @@
+package gostats
+
+import (
+	"math"
+	"sync"
+)
+
+// ChangePoint is a change of the mean of a stream, reported by CUSUM, PageHinkley and ADWIN.
+type ChangePoint struct {
+	// Index is the estimated index of the first value after the change, counting from 0 the values appended
+	// since the detector has been created.
+	Index int
+	// DetectedAt is the index of the value that triggered the detection.
+	DetectedAt int
+	// Magnitude is the estimated change of the mean: the mean of the values since Index minus the mean
+	// before.
+	Magnitude float64
+}
+
+var CUSUMPool = sync.Pool{
+	New: func() interface{} { return new(CUSUM) },
+}
+
+// CUSUMOptions configures a CUSUM. K and H are usually set from the standard deviation σ of the values: K to
+// half the shift to detect, and H to 4σ or 5σ.
+type CUSUMOptions struct {
+	// Mean is the expected mean of the values, unless WarmUp > 0.
+	Mean float64
+	// K is the slack: deviations from Mean smaller than K are ignored.
+	K float64
+	// H is the threshold of the cumulative sums.
+	H float64
+	// WarmUp is the number of first values used to estimate Mean, during which nothing is detected.
+	WarmUp int
+	// ResetOnChange restarts the detector after a change, including the warm-up. Otherwise, the change is
+	// reported again for every value until the cumulative sum goes back below H.
+	ResetOnChange bool
+}
+
+// CUSUM is Page's two-sided cumulative sum control chart: it detects a shift of the mean from its expected
+// value when S+ = max(0, S+ + x - Mean - K) or S- = max(0, S- + Mean - K - x) exceeds H. The change is estimated
+// to start after the last time the sum was 0.
+type CUSUM struct {
+	options CUSUMOptions
+	mean    float64
+	upper   cusumSide
+	lower   cusumSide
+	count   int
+	// warm-up
+	_warmUpSum   float64
+	_warmUpCount int
+}
+
+// cusumSide is a one-sided cumulative sum, and the values summed since it was 0.
+type cusumSide struct {
+	sum      float64
+	start    int
+	runSum   float64
+	runCount int
+}
+
+func (c *cusumSide) add(deviation float64, value float64, index int) {
+	c.sum = c.sum + deviation
+	if c.sum <= 0 {
+		c.sum = 0
+		c.start = index + 1
+		c.runSum = 0
+		c.runCount = 0
+		return
+	}
+	c.runSum = c.runSum + value
+	c.runCount++
+}
+
+// NewCUSUM returns a CUSUM. It panics if K < 0, H <= 0 or WarmUp < 0.
+func NewCUSUM(options CUSUMOptions) *CUSUM {
+	if options.K < 0 {
+		panic("CUSUM K must not be negative")
+	}
+	if !(options.H > 0) {
+		panic("CUSUM H must be positive")
+	}
+	if options.WarmUp < 0 {
+		panic("CUSUM WarmUp must not be negative")
+	}
+	c := CUSUMPool.Get().(*CUSUM)
+	c.options = options
+	c.count = 0
+	c.reset()
+	return c
+}
+
+func (c *CUSUM) CleanPool() {
+	CUSUMPool.Put(c)
+}
+
+func (c *CUSUM) reset() {
+	c.mean = c.options.Mean
+	c.upper = cusumSide{start: c.count}
+	c.lower = cusumSide{start: c.count}
+	c._warmUpSum = 0
+	c._warmUpCount = 0
+}
+
+// Append adds a value, and returns the change it reveals, if any.
+func (c *CUSUM) Append(value float64) (ChangePoint, bool) {
+	index := c.count
+	c.count++
+	if c._warmUpCount < c.options.WarmUp {
+		c._warmUpSum = c._warmUpSum + value
+		c._warmUpCount++
+		c.mean = c._warmUpSum / float64(c._warmUpCount)
+		c.upper.start = c.count
+		c.lower.start = c.count
+		return ChangePoint{}, false
+	}
+
+	c.upper.add(value-c.mean-c.options.K, value, index)
+	c.lower.add(c.mean-c.options.K-value, value, index)
+
+	side := &c.upper
+	if c.lower.sum > c.upper.sum {
+		side = &c.lower
+	}
+	if side.sum <= c.options.H {
+		return ChangePoint{}, false
+	}
+	change := ChangePoint{
+		Index:      side.start,
+		DetectedAt: index,
+		Magnitude:  side.runSum/float64(side.runCount) - c.mean,
+	}
+	if c.options.ResetOnChange {
+		c.reset()
+	}
+	return change, true
+}
+
+// Mean returns the expected mean, as estimated during the warm-up if any.
+func (c *CUSUM) Mean() float64 {
+	return c.mean
+}
+
+// Sums returns the cumulative sums S+ and S-.
+func (c *CUSUM) Sums() (upper float64, lower float64) {
+	return c.upper.sum, c.lower.sum
+}
+
+var PageHinkleyPool = sync.Pool{
+	New: func() interface{} { return new(PageHinkley) },
+}
+
+// PageHinkleyOptions configures a PageHinkley.
+type PageHinkleyOptions struct {
+	// Delta is the magnitude of the changes that are tolerated.
+	Delta float64
+	// Lambda is the detection threshold.
+	Lambda float64
+	// ResetOnChange restarts the detector after a change. Otherwise, the change is reported again for every
+	// value until the statistic goes back below Lambda.
+	ResetOnChange bool
+}
+
+// PageHinkley is the two-sided Page-Hinkley test: with x̄ the running mean, it detects an increase of the mean
+// when m = Σ(x - x̄ - Delta) rises more than Lambda above its minimum, and a decrease when
+// m' = Σ(x - x̄ + Delta) falls more than Lambda below its maximum. The change is estimated to start after the
+// extremum.
+type PageHinkley struct {
+	options PageHinkleyOptions
+	up      pageHinkleySide
+	down    pageHinkleySide
+	sum     float64
+	n       int
+	count   int
+}
+
+// pageHinkleySide is a cumulative sum, its extremum, and the values summed when it was reached.
+type pageHinkleySide struct {
+	m        float64
+	extremum float64
+	start    int
+	sumAt    float64
+	nAt      int
+}
+
+// NewPageHinkley returns a PageHinkley. It panics if Delta < 0 or Lambda <= 0.
+func NewPageHinkley(options PageHinkleyOptions) *PageHinkley {
+	if options.Delta < 0 {
+		panic("PageHinkley Delta must not be negative")
+	}
+	if !(options.Lambda > 0) {
+		panic("PageHinkley Lambda must be positive")
+	}
+	p := PageHinkleyPool.Get().(*PageHinkley)
+	p.options = options
+	p.count = 0
+	p.reset()
+	return p
+}
+
+func (p *PageHinkley) CleanPool() {
+	PageHinkleyPool.Put(p)
+}
+
+func (p *PageHinkley) reset() {
+	p.up = pageHinkleySide{start: p.count}
+	p.down = pageHinkleySide{start: p.count}
+	p.sum = 0
+	p.n = 0
+}
+
+// Append adds a value, and returns the change it reveals, if any.
+func (p *PageHinkley) Append(value float64) (ChangePoint, bool) {
+	index := p.count
+	p.count++
+	p.sum = p.sum + value
+	p.n++
+	mean := p.sum / float64(p.n)
+
+	p.up.m = p.up.m + value - mean - p.options.Delta
+	// Ties move the extremum too, so that a change after a flat series starts after it.
+	if p.up.m <= p.up.extremum {
+		p.up.extremum, p.up.start, p.up.sumAt, p.up.nAt = p.up.m, p.count, p.sum, p.n
+	}
+	p.down.m = p.down.m + value - mean + p.options.Delta
+	if p.down.m >= p.down.extremum {
+		p.down.extremum, p.down.start, p.down.sumAt, p.down.nAt = p.down.m, p.count, p.sum, p.n
+	}
+
+	side := &p.up
+	if p.down.extremum-p.down.m > p.up.m-p.up.extremum {
+		side = &p.down
+	}
+	if math.Abs(side.m-side.extremum) <= p.options.Lambda || side.nAt == p.n {
+		return ChangePoint{}, false
+	}
+	change := ChangePoint{
+		Index:      side.start,
+		DetectedAt: index,
+		Magnitude:  (p.sum-side.sumAt)/float64(p.n-side.nAt) - side.sumAt/float64(side.nAt),
+	}
+	if p.options.ResetOnChange {
+		p.reset()
+	}
+	return change, true
+}
+
+// Mean returns the mean of the values since the last reset.
+func (p *PageHinkley) Mean() float64 {
+	return p.sum / float64(p.n)
+}
+
+var ADWINPool = sync.Pool{
+	New: func() interface{} { return new(ADWIN) },
+}
+
+// DefaultADWINDelta is the confidence used by ADWIN when none is given.
+const DefaultADWINDelta = 0.002
+
+// ADWINOptions configures an ADWIN.
+type ADWINOptions struct {
+	// Delta is the confidence of the detection: a smaller Delta gives less false positives, but detects changes
+	// later. DefaultADWINDelta if 0.
+	Delta float64
+	// MaxBuckets is the number of buckets of each size, 5 if 0: more buckets check more split points.
+	MaxBuckets int
+	// ResetOnChange empties the window after a change. Otherwise, the older buckets are dropped until the
+	// means of the window parts agree, which may take a few values: the change may be reported again meanwhile.
+	ResetOnChange bool
+}
+
+// ADWIN is the ADaptive WINdowing algorithm of Bifet & Gavaldà: it keeps a window of the recent values, and
+// drops its older part whenever the means of the two parts differ by more than a bound depending on Delta and
+// the variance of the window. The window is compressed into an exponential histogram of buckets, so it uses
+// O(MaxBuckets log n) memory and time per Append.
+type ADWIN struct {
+	delta         float64
+	maxBuckets    int
+	resetOnChange bool
+	// buckets, from the oldest to the most recent, their sizes being decreasing powers of two
+	buckets []adwinBucket
+	width   int
+	sum     float64
+	m2      float64
+	count   int
+}
+
+type adwinBucket struct {
+	n   int
+	sum float64
+	m2  float64
+}
+
+// merge returns the bucket of the values of b and o, combining the variances with the parallel formula.
+func (b adwinBucket) merge(o adwinBucket) adwinBucket {
+	n := b.n + o.n
+	delta := o.sum/float64(o.n) - b.sum/float64(b.n)
+	return adwinBucket{
+		n:   n,
+		sum: b.sum + o.sum,
+		m2:  b.m2 + o.m2 + delta*delta*float64(b.n)*float64(o.n)/float64(n),
+	}
+}
+
+// adwinMinWindow is the minimum number of values in each part of a split.
+const adwinMinWindow = 5
+
+// NewADWIN returns an ADWIN. It panics unless 0 <= Delta < 1 and MaxBuckets >= 0.
+func NewADWIN(options ADWINOptions) *ADWIN {
+	if !(options.Delta >= 0 && options.Delta < 1) {
+		panic("ADWIN Delta must be in [0, 1)")
+	}
+	if options.MaxBuckets < 0 {
+		panic("ADWIN MaxBuckets must not be negative")
+	}
+	if options.Delta == 0 {
+		options.Delta = DefaultADWINDelta
+	}
+	if options.MaxBuckets == 0 {
+		options.MaxBuckets = 5
+	}
+	a := ADWINPool.Get().(*ADWIN)
+	a.delta = options.Delta
+	a.maxBuckets = options.MaxBuckets
+	a.resetOnChange = options.ResetOnChange
+	a.count = 0
+	a.reset()
+	return a
+}
+
+func (a *ADWIN) CleanPool() {
+	ADWINPool.Put(a)
+}
+
+func (a *ADWIN) reset() {
+	a.buckets = a.buckets[:0]
+	a.width = 0
+	a.sum = 0
+	a.m2 = 0
+}
+
+// Append adds a value, and returns the change it reveals, if any.
+func (a *ADWIN) Append(value float64) (ChangePoint, bool) {
+	index := a.count
+	a.count++
+
+	a.add(adwinBucket{n: 1, sum: value})
+	a.compress()
+
+	split, magnitude, found := a.cut()
+	if !found {
+		return ChangePoint{}, false
+	}
+	change := ChangePoint{
+		Index:      a.count - a.width + split,
+		DetectedAt: index,
+		Magnitude:  magnitude,
+	}
+	if a.resetOnChange {
+		a.reset()
+		return change, true
+	}
+	for found {
+		a.remove(a.buckets[0])
+		a.buckets = append(a.buckets[:0], a.buckets[1:]...)
+		_, _, found = a.cut()
+	}
+	return change, true
+}
+
+// add appends the bucket to the window.
+func (a *ADWIN) add(b adwinBucket) {
+	if a.width == 0 {
+		a.sum, a.m2 = b.sum, b.m2
+	} else {
+		merged := adwinBucket{n: a.width, sum: a.sum, m2: a.m2}.merge(b)
+		a.sum, a.m2 = merged.sum, merged.m2
+	}
+	a.width = a.width + b.n
+	a.buckets = append(a.buckets, b)
+}
+
+// remove removes the values of the oldest bucket b from the window statistics.
+func (a *ADWIN) remove(b adwinBucket) {
+	n := a.width - b.n
+	if n == 0 {
+		a.width, a.sum, a.m2 = 0, 0, 0
+		return
+	}
+	rest := (a.sum - b.sum) / float64(n)
+	delta := rest - b.sum/float64(b.n)
+	a.m2 = a.m2 - b.m2 - delta*delta*float64(b.n)*float64(n)/float64(a.width)
+	if a.m2 < 0 {
+		a.m2 = 0
+	}
+	a.sum = a.sum - b.sum
+	a.width = n
+}
+
+// compress merges the two oldest buckets of a size when there are more than maxBuckets of them.
+func (a *ADWIN) compress() {
+	// Buckets of the same size are contiguous, the most recent ones being the smallest.
+	end := len(a.buckets)
+	for end > 0 {
+		size := a.buckets[end-1].n
+		start := end - 1
+		for start > 0 && a.buckets[start-1].n == size {
+			start--
+		}
+		if end-start <= a.maxBuckets {
+			return
+		}
+		a.buckets[start] = a.buckets[start].merge(a.buckets[start+1])
+		a.buckets = append(a.buckets[:start+1], a.buckets[start+2:]...)
+		end = start + 1
+	}
+}
+
+// cut returns whether the window must be shrunk: whether there is a split of the window, at a bucket boundary,
+// whose older and more recent parts have means differing by more than the bound. If so, it returns the split
+// exceeding the bound the most, as the number of values before it, and the difference of the means.
+func (a *ADWIN) cut() (split int, magnitude float64, found bool) {
+	if a.width < 2*adwinMinWindow {
+		return 0, 0, false
+	}
+	n := float64(a.width)
+	variance := a.m2 / n
+	dd := math.Log(2 * math.Log(n) / a.delta)
+
+	n0, sum0 := 0, 0.0
+	margin := 0.0
+	for _, b := range a.buckets[:len(a.buckets)-1] {
+		n0 = n0 + b.n
+		sum0 = sum0 + b.sum
+		n1 := a.width - n0
+		if n1 < adwinMinWindow {
+			break
+		}
+		if n0 < adwinMinWindow {
+			continue
+		}
+		m := 1/float64(n0-adwinMinWindow+1) + 1/float64(n1-adwinMinWindow+1)
+		epsilon := math.Sqrt(2*m*variance*dd) + 2.0/3*dd*m
+		diff := (a.sum-sum0)/float64(n1) - sum0/float64(n0)
+		if math.Abs(diff)-epsilon > margin {
+			split, magnitude, found = n0, diff, true
+			margin = math.Abs(diff) - epsilon
+		}
+	}
+	return split, magnitude, found
+}
+
+// Width returns the number of values in the window.
+func (a *ADWIN) Width() int {
+	return a.width
+}
+
+// Mean returns the mean of the window.
+func (a *ADWIN) Mean() float64 {
+	return a.sum / float64(a.width)
+}
+
+// Variance returns the population variance of the window.
+func (a *ADWIN) Variance() float64 {
+	return a.m2 / float64(a.width)
+}
@@ -0,0 +1,175 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math/rand"
+	"testing"
+)
+
+// shiftedValues returns n normal values of standard deviation sigma, whose mean goes from 0 to shift at the
+// index at.
+func shiftedValues(seed int64, n, at int, shift, sigma float64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = sigma * r.NormFloat64()
+		if i >= at {
+			values[i] = values[i] + shift
+		}
+	}
+	return values
+}
+
+type changeDetector interface {
+	Append(value float64) (ChangePoint, bool)
+}
+
+// detect returns the changes detected in values.
+func detect(d changeDetector, values []float64) []ChangePoint {
+	var changes []ChangePoint
+	for _, value := range values {
+		if change, ok := d.Append(value); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func TestCUSUM(t *testing.T) {
+
+	t.Run("Shifts", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, shift := range []float64{2, -2} {
+			c := NewCUSUM(CUSUMOptions{K: 0.5, H: 8, WarmUp: 50, ResetOnChange: true})
+			changes := detect(c, shiftedValues(11, 400, 200, shift, 1))
+			g.Expect(changes).To(HaveLen(1))
+			g.Expect(changes[0].Index).To(BeNumerically("~", 200, 10))
+			g.Expect(changes[0].DetectedAt).To(BeNumerically("~", 205, 5))
+			g.Expect(changes[0].Magnitude).To(BeNumerically("~", shift, 1))
+			upper, lower := c.Sums()
+			g.Expect(upper).To(BeNumerically("<=", 8))
+			g.Expect(lower).To(BeNumerically("<=", 8))
+			c.CleanPool()
+		}
+	})
+
+	t.Run("Warm-up", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		values := shiftedValues(2, 400, 200, 2, 1)
+		for i := range values {
+			values[i] = values[i] + 10
+		}
+		c := NewCUSUM(CUSUMOptions{K: 0.5, H: 5, WarmUp: 50})
+		changes := detect(c, values)
+		g.Expect(c.Mean()).To(BeNumerically("~", 10, 0.3))
+		g.Expect(len(changes)).To(BeNumerically(">", 150))
+		g.Expect(changes[0].Index).To(BeNumerically("~", 200, 3))
+		// without reset, the change is reported again
+		g.Expect(changes[1].Index).To(Equal(changes[0].Index))
+		g.Expect(changes[1].DetectedAt).To(Equal(changes[0].DetectedAt + 1))
+		c.CleanPool()
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(func() { NewCUSUM(CUSUMOptions{K: -1, H: 5}) }).To(Panic())
+		g.Expect(func() { NewCUSUM(CUSUMOptions{K: 1}) }).To(Panic())
+		g.Expect(func() { NewCUSUM(CUSUMOptions{H: 1, WarmUp: -1}) }).To(Panic())
+	})
+}
+
+func TestPageHinkley(t *testing.T) {
+
+	t.Run("Shifts", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, shift := range []float64{2, -2} {
+			p := NewPageHinkley(PageHinkleyOptions{Delta: 0.5, Lambda: 10, ResetOnChange: true})
+			changes := detect(p, shiftedValues(3, 400, 200, shift, 1))
+			g.Expect(changes).NotTo(BeEmpty())
+			g.Expect(changes[0].Index).To(BeNumerically("~", 200, 10))
+			g.Expect(changes[0].DetectedAt).To(BeNumerically("~", 205, 10))
+			g.Expect(changes[0].Magnitude).To(BeNumerically("~", shift, 1))
+			p.CleanPool()
+		}
+	})
+
+	t.Run("Stationary", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p := NewPageHinkley(PageHinkleyOptions{Delta: 0.5, Lambda: 10})
+		g.Expect(detect(p, shiftedValues(4, 2000, 2000, 0, 1))).To(BeEmpty())
+		g.Expect(p.Mean()).To(BeNumerically("~", 0, 0.1))
+		p.CleanPool()
+	})
+
+	t.Run("Flat then step without Delta", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, step := range []float64{1, -1} {
+			values := make([]float64, 200)
+			for i := 100; i < len(values); i++ {
+				values[i] = step
+			}
+			p := NewPageHinkley(PageHinkleyOptions{Delta: 0, Lambda: 5, ResetOnChange: true})
+			changes := detect(p, values)
+			g.Expect(changes).NotTo(BeEmpty())
+			g.Expect(changes[0].Index).To(Equal(100))
+			g.Expect(changes[0].DetectedAt).To(BeNumerically("<", 110))
+			g.Expect(changes[0].Magnitude).To(Equal(step))
+			p.CleanPool()
+		}
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(func() { NewPageHinkley(PageHinkleyOptions{Delta: -1, Lambda: 5}) }).To(Panic())
+		g.Expect(func() { NewPageHinkley(PageHinkleyOptions{}) }).To(Panic())
+	})
+}
+
+func TestADWIN(t *testing.T) {
+
+	t.Run("Shifts", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, shift := range []float64{1, -1} {
+			a := NewADWIN(ADWINOptions{})
+			changes := detect(a, shiftedValues(5, 2000, 1000, shift, 0.5))
+			g.Expect(changes).NotTo(BeEmpty())
+			g.Expect(changes[0].DetectedAt).To(BeNumerically(">=", 1000))
+			g.Expect(changes[0].DetectedAt).To(BeNumerically("<", 1100))
+			g.Expect(changes[0].Index).To(BeNumerically("~", 1000, 20))
+			g.Expect(changes[0].Magnitude).To(BeNumerically("~", shift, 0.2))
+			for _, change := range changes {
+				g.Expect(change.DetectedAt).To(BeNumerically("<", 1100))
+			}
+			// only the values after the change are left
+			g.Expect(a.Width()).To(BeNumerically("<", 1100))
+			g.Expect(a.Mean()).To(BeNumerically("~", shift, 0.1))
+			g.Expect(a.Variance()).To(BeNumerically("~", 0.25, 0.05))
+			g.Expect(len(a.buckets)).To(BeNumerically("<", 60))
+			a.CleanPool()
+		}
+	})
+
+	t.Run("Reset on change", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		a := NewADWIN(ADWINOptions{Delta: 0.01, MaxBuckets: 3, ResetOnChange: true})
+		changes := detect(a, shiftedValues(6, 1500, 1000, 1, 0.5))
+		g.Expect(changes).To(HaveLen(1))
+		g.Expect(a.Width()).To(Equal(1500 - changes[0].DetectedAt - 1))
+		a.CleanPool()
+	})
+
+	t.Run("Stationary", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		a := NewADWIN(ADWINOptions{})
+		g.Expect(detect(a, shiftedValues(7, 5000, 5000, 0, 1))).To(BeEmpty())
+		g.Expect(a.Width()).To(Equal(5000))
+		g.Expect(a.Mean()).To(BeNumerically("~", 0, 0.05))
+		a.CleanPool()
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(func() { NewADWIN(ADWINOptions{Delta: 1}) }).To(Panic())
+		g.Expect(func() { NewADWIN(ADWINOptions{MaxBuckets: -1}) }).To(Panic())
+	})
+}
@@ -0,0 +1,159 @@
+package gostats
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var ConcurrentSampleStreamPool = sync.Pool{
+	New: func() interface{} { return new(ConcurrentSampleStream) },
+}
+
+// ConcurrentSampleStream is a SampleStream that can be appended to and read from several goroutines.
+//
+// Values are appended to shards, each one being a SampleStream protected by its own mutex. The shard is picked
+// through a sync.Pool of shard hints, which keeps a cache per P: goroutines running on different Ps usually get
+// different shards, without any write to memory shared by the Appends, so they rarely wait for each other.
+// Reads merge the shards, see SampleStream.Merge: they lock every shard, so they see all the values appended
+// before them, and none of the values appended after.
+//
+// Since concurrent values have no order, the streak counters of the merged stream are not meaningful.
+type ConcurrentSampleStream struct {
+	shards []concurrentShard
+	mask   uint32
+	// hints holds *concurrentHint, next numbers the new ones
+	hints sync.Pool
+	next  uint32
+}
+
+// concurrentHint is the index of the shard used by a P.
+type concurrentHint struct {
+	index uint32
+}
+
+type concurrentShard struct {
+	mu     sync.Mutex
+	stream SampleStream
+	// keeps the shards on distinct cache lines
+	_ [64]byte
+}
+
+// NewConcurrentSampleStream returns an empty ConcurrentSampleStream with the given number of shards, or
+// GOMAXPROCS shards if shards < 1, rounded up to a power of two.
+func NewConcurrentSampleStream(shards int) *ConcurrentSampleStream {
+	if shards < 1 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	c := ConcurrentSampleStreamPool.Get().(*ConcurrentSampleStream)
+	c.initEmptyValues(shards)
+	return c
+}
+
+// CleanPool puts c back in the pool. c must not be used concurrently anymore.
+func (c *ConcurrentSampleStream) CleanPool() {
+	ConcurrentSampleStreamPool.Put(c)
+}
+
+func (c *ConcurrentSampleStream) initEmptyValues(shards int) {
+	// A power of two number of shards picks them with a mask rather than a division.
+	for shards&(shards-1) != 0 {
+		shards = shards + shards&-shards
+	}
+	if cap(c.shards) < shards {
+		c.shards = make([]concurrentShard, shards)
+	}
+	c.shards = c.shards[:shards]
+	for i := range c.shards {
+		c.shards[i].stream.initEmptyValues()
+	}
+	c.mask = uint32(shards - 1)
+	if c.hints.New == nil {
+		c.hints.New = func() interface{} {
+			return &concurrentHint{index: atomic.AddUint32(&c.next, 1) - 1}
+		}
+	}
+}
+
+// Append adds a value. It is safe for concurrent use.
+func (c *ConcurrentSampleStream) Append(value float64) {
+	hint := c.hints.Get().(*concurrentHint)
+	shard := &c.shards[hint.index&c.mask]
+	shard.mu.Lock()
+	shard.stream.Append(value)
+	shard.mu.Unlock()
+	c.hints.Put(hint)
+}
+
+// AppendMany adds the values to a single shard, locking it once. It is safe for concurrent use.
+func (c *ConcurrentSampleStream) AppendMany(values []float64) {
+	hint := c.hints.Get().(*concurrentHint)
+	shard := &c.shards[hint.index&c.mask]
+	shard.mu.Lock()
+	shard.stream.AppendMany(values)
+	shard.mu.Unlock()
+	c.hints.Put(hint)
+}
+
+// snapshot merges the shards into dst.
+func (c *ConcurrentSampleStream) snapshot(dst *SampleStream) {
+	dst.initEmptyValues()
+	for i := range c.shards {
+		c.shards[i].mu.Lock()
+	}
+	for i := range c.shards {
+		dst.Merge(&c.shards[i].stream)
+	}
+	for i := range c.shards {
+		c.shards[i].mu.Unlock()
+	}
+}
+
+// Snapshot returns a SampleStream, taken from the pool, holding the values appended so far. Use it to read
+// several statistics consistently.
+func (c *ConcurrentSampleStream) Snapshot() *SampleStream {
+	s := NewSampleStream()
+	c.snapshot(s)
+	return s
+}
+
+// Len returns the number of appended values.
+func (c *ConcurrentSampleStream) Len() int {
+	var s SampleStream
+	c.snapshot(&s)
+	return s.Len()
+}
+
+// Bounds returns the minimum and maximum values of the stream.
+func (c *ConcurrentSampleStream) Bounds() (min float64, max float64) {
+	var s SampleStream
+	c.snapshot(&s)
+	return s.Bounds()
+}
+
+// Sum returns the sum of the stream.
+func (c *ConcurrentSampleStream) Sum() float64 {
+	var s SampleStream
+	c.snapshot(&s)
+	return s.Sum()
+}
+
+// Mean returns the arithmetic mean of the stream.
+func (c *ConcurrentSampleStream) Mean() float64 {
+	var s SampleStream
+	c.snapshot(&s)
+	return s.Mean()
+}
+
+func (c *ConcurrentSampleStream) Variance() float64 {
+	var s SampleStream
+	c.snapshot(&s)
+	return s.Variance()
+}
+
+// StdDev returns the sample standard deviation of the stream.
+func (c *ConcurrentSampleStream) StdDev() float64 {
+	var s SampleStream
+	c.snapshot(&s)
+	return s.StdDev()
+}
@@ -0,0 +1,101 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentSampleStream(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 10000)
+	for i := range values {
+		values[i] = r.NormFloat64()
+	}
+	want := NewSampleStream()
+	want.AppendMany(values)
+
+	t.Run("Concurrent appends", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := NewConcurrentSampleStream(4)
+
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < len(values); i += 8 {
+					if i%100 == 0 {
+						c.AppendMany(values[i : i+1])
+					} else {
+						c.Append(values[i])
+					}
+					if i%1000 == 0 {
+						c.Mean()
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		s := c.Snapshot()
+		g.Expect(s.Len()).To(Equal(len(values)))
+		g.Expect(s.Mean()).To(BeNumerically("~", want.Mean(), 1e-12))
+		g.Expect(s.Variance()).To(BeNumerically("~", want.Variance(), 1e-12))
+		g.Expect(s.Skewness()).To(BeNumerically("~", want.Skewness(), 1e-9))
+		s.CleanPool()
+
+		g.Expect(c.Len()).To(Equal(len(values)))
+		g.Expect(c.Sum()).To(BeNumerically("~", want.Sum(), 1e-9))
+		g.Expect(c.Mean()).To(BeNumerically("~", want.Mean(), 1e-12))
+		g.Expect(c.StdDev()).To(BeNumerically("~", want.StdDev(), 1e-12))
+		min, max := c.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{want.min, want.max}))
+		c.CleanPool()
+	})
+
+	t.Run("Default shards", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		c := NewConcurrentSampleStream(0)
+		g.Expect(len(c.shards)).To(BeNumerically(">=", 1))
+		g.Expect(c.Len()).To(Equal(0))
+		c.AppendMany(values)
+		g.Expect(c.Mean()).To(BeNumerically("~", want.Mean(), 1e-12))
+		c.CleanPool()
+	})
+}
+
+// BenchmarkConcurrentSampleStream compares the Appends of parallel goroutines to a ConcurrentSampleStream and to
+// a SampleStream behind a mutex. Run it with -cpu 1,2,4,8 to see how they scale.
+func BenchmarkConcurrentSampleStream(b *testing.B) {
+	b.Run("Mutex", func(b *testing.B) {
+		var mu sync.Mutex
+		s := NewSampleStream()
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			value := 0.0
+			for pb.Next() {
+				mu.Lock()
+				s.Append(value)
+				mu.Unlock()
+				value++
+			}
+		})
+		s.CleanPool()
+	})
+
+	b.Run("ConcurrentSampleStream", func(b *testing.B) {
+		c := NewConcurrentSampleStream(0)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			value := 0.0
+			for pb.Next() {
+				c.Append(value)
+				value++
+			}
+		})
+		c.CleanPool()
+	})
+}
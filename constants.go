@@ -2,9 +2,16 @@ package gostats
 
 type CorrelationType string
 
+// CorrelationPearson is the Pearson product-moment correlation.
 const CorrelationPearson CorrelationType = "Pearson"
+
+// CorrelationSpearman is the Spearman rank correlation.
 const CorrelationSpearman CorrelationType = "SpearMan"
+
+// CorrelationCustom is the correlation registered under its name with RegisterCorrelation.
 const CorrelationCustom CorrelationType = "Custom"
+
+// CorrelationKendall is the Kendall tau-b rank correlation.
 const CorrelationKendall CorrelationType = "Kendal"
 
 func (ct CorrelationType) String() string {
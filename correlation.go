@@ -0,0 +1,326 @@
+package gostats
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/a-lucas/go-stats/stats"
+)
+
+// CorrelationResult is the result of a correlation test between two Samples.
+type CorrelationResult struct {
+	// Type is the correlation that has been computed.
+	Type CorrelationType
+
+	// N is the number of paired values.
+	N int
+
+	// Coefficient is the correlation coefficient, in [-1, 1].
+	Coefficient float64
+
+	// P is the two-sided p-value for the null hypothesis that there
+	// is no association between the two Samples. It is NaN when it
+	// cannot be computed (less than 3 values, or a constant Sample).
+	P float64
+}
+
+// CorrelationTest computes the correlation with another Sample, together
+// with its significance.
+//
+// Both Samples need to be created with withOriginal == true, as the
+// correlation is computed on the original order. It panics on misuse,
+// see CorrelationTestE.
+func (s *Sample) CorrelationTest(data *Sample, correlationType CorrelationType) *CorrelationResult {
+	res, err := s.CorrelationTestE(data, correlationType)
+	switch err {
+	case nil:
+		return res
+	case ErrSampleSize, ErrMismatchedSamples:
+		return &CorrelationResult{Type: correlationType, N: s.nb, Coefficient: math.NaN(), P: math.NaN()}
+	}
+	panic(err)
+}
+
+// CorrelationTestE is like CorrelationTest, but returns an error instead
+// of panicking.
+func (s *Sample) CorrelationTestE(data *Sample, correlationType CorrelationType) (*CorrelationResult, error) {
+	var fn CorrelationFunc
+	switch correlationType {
+	case CorrelationPearson, CorrelationSpearman, CorrelationKendall:
+	default:
+		correlationsMu.RLock()
+		fn = correlations[correlationType]
+		correlationsMu.RUnlock()
+		if fn == nil {
+			return nil, ErrUnknownCorrelation
+		}
+	}
+
+	if correlationType != CorrelationPearson {
+		if err := s.validatePair(data); err != nil {
+			return nil, err
+		}
+	}
+
+	switch correlationType {
+	case CorrelationPearson:
+		r, err := s.correlationPearson(data)
+		if err != nil {
+			return nil, err
+		}
+		return &CorrelationResult{
+			Type:        CorrelationPearson,
+			N:           s.nb,
+			Coefficient: r,
+			P:           correlationTTestP(r, s.nb),
+		}, nil
+	case CorrelationSpearman:
+		return s.correlationSpearman(data), nil
+	case CorrelationKendall:
+		return s.correlationKendall(data), nil
+	default:
+		// The significance of a registered correlation is unknown.
+		return &CorrelationResult{
+			Type:        correlationType,
+			N:           s.nb,
+			Coefficient: fn(s.original, data.original),
+			P:           math.NaN(),
+		}, nil
+	}
+}
+
+// CorrelationFunc computes a correlation coefficient between the
+// original values of two Samples. x and y always have the same,
+// non-zero, length.
+type CorrelationFunc func(x, y []float64) float64
+
+var (
+	correlationsMu sync.RWMutex
+	correlations   = make(map[CorrelationType]CorrelationFunc)
+)
+
+// RegisterCorrelation makes a correlation function available under the
+// given name, so that it can be used with Sample.Correlation and
+// Sample.CorrelationTest. Registering a function under CorrelationCustom
+// defines what CorrelationCustom computes.
+//
+// If RegisterCorrelation is called twice with the same name, if name is
+// one of the built-in correlations, or if fn is nil, it panics.
+func RegisterCorrelation(name CorrelationType, fn CorrelationFunc) {
+	if fn == nil {
+		panic("RegisterCorrelation: correlation function is nil")
+	}
+	switch name {
+	case CorrelationPearson, CorrelationSpearman, CorrelationKendall:
+		panic("RegisterCorrelation: cannot override built-in " + name.String())
+	}
+
+	correlationsMu.Lock()
+	defer correlationsMu.Unlock()
+	if _, dup := correlations[name]; dup {
+		panic("RegisterCorrelation: called twice for " + name.String())
+	}
+	correlations[name] = fn
+}
+
+// Correlations returns a sorted list of the registered correlation names.
+func Correlations() []CorrelationType {
+	correlationsMu.RLock()
+	defer correlationsMu.RUnlock()
+	list := make([]CorrelationType, 0, len(correlations))
+	for name := range correlations {
+		list = append(list, name)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// correlationSpearman computes the Spearman rank correlation on the
+// original order of two validated Samples. Tied values are given their average rank, so this is
+// the Pearson correlation of the ranks.
+func (s *Sample) correlationSpearman(data *Sample) *CorrelationResult {
+	res := &CorrelationResult{Type: CorrelationSpearman, N: s.nb, Coefficient: math.NaN(), P: math.NaN()}
+
+	rx, tx := stats.Rank(s.original)
+	ry, ty := stats.Rank(data.original)
+
+	d2 := 0.0
+	for i := range rx {
+		d := rx[i] - ry[i]
+		d2 += d * d
+	}
+
+	// Σ(r - r̄)² for untied ranks is (n³ - n) / 12, and each group of t
+	// ties removes (t³ - t) / 12 from it.
+	n := float64(s.nb)
+	base := (n*n*n - n) / 12
+	sx := base - stats.TieCorrection(tx)/12
+	sy := base - stats.TieCorrection(ty)/12
+	if sx == 0 || sy == 0 {
+		res.Coefficient = 0
+		return res
+	}
+
+	res.Coefficient = (sx + sy - d2) / (2 * math.Sqrt(sx*sy))
+	res.P = correlationTTestP(res.Coefficient, s.nb)
+	return res
+}
+
+// correlationKendall computes Kendall's tau-b on the original order of
+// two validated Samples using Knight's O(n log n) algorithm: the pairs are sorted by x then
+// y, and the number of discordant pairs is the number of swaps needed
+// to merge sort the resulting y sequence.
+//
+// The p-value uses the normal approximation of the tie-corrected
+// variance of S = n_c - n_d.
+func (s *Sample) correlationKendall(data *Sample) *CorrelationResult {
+	res := &CorrelationResult{Type: CorrelationKendall, N: s.nb, Coefficient: math.NaN(), P: math.NaN()}
+
+	x, y := s.original, data.original
+	order := make([]int, s.nb)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if x[a] != x[b] {
+			return x[a] < x[b]
+		}
+		return y[a] < y[b]
+	})
+
+	ys := make([]float64, s.nb)
+	for i, o := range order {
+		ys[i] = y[o]
+	}
+
+	// Pairs tied on x (n1) and tied on both x and y (n3).
+	var n3 int64
+	xTies := kendallTies{}
+	for i := 0; i < len(order); {
+		j := i
+		for ; j < len(order) && x[order[j]] == x[order[i]]; j++ {
+		}
+		xTies.add(int64(j - i))
+		for k := i; k < j; {
+			l := k
+			for ; l < j && ys[l] == ys[k]; l++ {
+			}
+			n3 += int64(l-k) * int64(l-k-1) / 2
+			k = l
+		}
+		i = j
+	}
+	n1 := xTies.pairs
+
+	swaps := mergeSortSwaps(ys, make([]float64, len(ys)))
+
+	// Pairs tied on y (n2), now that ys is sorted.
+	yTies := kendallTies{}
+	for i := 0; i < len(ys); {
+		j := i
+		for ; j < len(ys) && ys[j] == ys[i]; j++ {
+		}
+		yTies.add(int64(j - i))
+		i = j
+	}
+	n2 := yTies.pairs
+
+	n := int64(s.nb)
+	n0 := n * (n - 1) / 2
+	S := float64(n0 - n1 - n2 + n3 - 2*swaps)
+	denom := math.Sqrt(float64(n0-n1) * float64(n0-n2))
+	if denom == 0 {
+		res.Coefficient = 0
+		return res
+	}
+	res.Coefficient = S / denom
+
+	if n < 3 {
+		return res
+	}
+	fn := float64(n)
+	v0 := fn * (fn - 1) * (2*fn + 5)
+	variance := (v0-xTies.v0-yTies.v0)/18 +
+		xTies.v1*yTies.v1/(2*fn*(fn-1)) +
+		xTies.v2*yTies.v2/(9*fn*(fn-1)*(fn-2))
+	z := S / math.Sqrt(variance)
+	res.P = 2 * math.Min(stats.StdNormal.CDF(z), 1-stats.StdNormal.CDF(z))
+	return res
+}
+
+// kendallTies accumulates, for each group of t tied values, the terms
+// needed by Kendall's tau-b and its variance.
+type kendallTies struct {
+	pairs int64   // Σ t(t-1)/2
+	v0    float64 // Σ t(t-1)(2t+5)
+	v1    float64 // Σ t(t-1)
+	v2    float64 // Σ t(t-1)(t-2)
+}
+
+func (k *kendallTies) add(t int64) {
+	if t < 2 {
+		return
+	}
+	ft := float64(t)
+	k.pairs += t * (t - 1) / 2
+	k.v0 += ft * (ft - 1) * (2*ft + 5)
+	k.v1 += ft * (ft - 1)
+	k.v2 += ft * (ft - 1) * (ft - 2)
+}
+
+// mergeSortSwaps sorts xs in place with a bottom-up merge sort and
+// returns the number of inversions, ie the number of pairs i < j with
+// xs[i] > xs[j]. Equal values are not counted. buf must be at least
+// as long as xs.
+func mergeSortSwaps(xs, buf []float64) int64 {
+	var swaps int64
+	n := len(xs)
+	src, dst := xs, buf[:n]
+	for width := 1; width < n; width *= 2 {
+		for lo := 0; lo < n; lo += 2 * width {
+			mid, hi := lo+width, lo+2*width
+			if mid > n {
+				mid = n
+			}
+			if hi > n {
+				hi = n
+			}
+			i, j, o := lo, mid, lo
+			for i < mid && j < hi {
+				if src[j] < src[i] {
+					dst[o] = src[j]
+					swaps += int64(mid - i)
+					j++
+				} else {
+					dst[o] = src[i]
+					i++
+				}
+				o++
+			}
+			o += copy(dst[o:], src[i:mid])
+			copy(dst[o:], src[j:hi])
+		}
+		src, dst = dst, src
+	}
+	if n > 0 && &src[0] != &xs[0] {
+		copy(xs, src)
+	}
+	return swaps
+}
+
+// correlationTTestP returns the two-sided p-value of a correlation
+// coefficient r over n pairs, using the t-distribution with n - 2
+// degrees of freedom.
+func correlationTTestP(r float64, n int) float64 {
+	if n < 3 || math.IsNaN(r) {
+		return math.NaN()
+	}
+	if math.Abs(r) >= 1 {
+		return 0
+	}
+	dof := float64(n - 2)
+	t := r * math.Sqrt(dof/(1-r*r))
+	return 2 * (1 - stats.TDist{V: dof}.CDF(math.Abs(t)))
+}
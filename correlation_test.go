@@ -0,0 +1,158 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCorrelation(t *testing.T) {
+
+	t.Run("Spearman", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		iq := NewSampleWithValue([]float64{106, 86, 100, 101, 99, 103, 97, 113, 112, 110}, true)
+		tv := NewSampleWithValue([]float64{7, 0, 27, 50, 28, 29, 20, 12, 6, 17}, true)
+
+		g.Expect(iq.Correlation(tv, CorrelationSpearman)).To(BeNumerically("~", -29.0/165, 1e-12))
+
+		res := iq.CorrelationTest(tv, CorrelationSpearman)
+		g.Expect(res.Type).To(Equal(CorrelationSpearman))
+		g.Expect(res.N).To(Equal(10))
+		g.Expect(res.P).To(BeNumerically("~", 0.6272, 1e-4))
+	})
+
+	t.Run("Spearman with ties", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s1 := NewSampleWithValue([]float64{1, 2, 2, 3}, true)
+		s2 := NewSampleWithValue([]float64{1, 2, 3, 4}, true)
+		g.Expect(s1.Correlation(s2, CorrelationSpearman)).To(BeNumerically("~", 4.5/math.Sqrt(22.5), 1e-12))
+	})
+
+	t.Run("Spearman monotonic", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s1 := NewSampleWithValue([]float64{1, 2, 3, 4, 5}, true)
+		s2 := NewSampleWithValue([]float64{1, 4, 9, 16, 25}, true)
+		res := s1.CorrelationTest(s2, CorrelationSpearman)
+		g.Expect(res.Coefficient).To(Equal(1.0))
+		g.Expect(res.P).To(Equal(0.0))
+	})
+
+	t.Run("Spearman invalid", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s1 := NewSampleWithValue([]float64{1, 2, 3}, true)
+		s2 := NewSampleWithValue([]float64{1, 2}, true)
+		s3 := NewSampleWithValue([]float64{1, 2, 3}, false)
+		g.Expect(math.IsNaN(s1.Correlation(s2, CorrelationSpearman))).To(BeTrue())
+		g.Expect(func() { s1.Correlation(s3, CorrelationSpearman) }).To(Panic())
+	})
+
+	t.Run("Kendall", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s1 := NewSampleWithValue([]float64{12, 2, 1, 12, 2}, true)
+		s2 := NewSampleWithValue([]float64{1, 4, 7, 1, 0}, true)
+		res := s1.CorrelationTest(s2, CorrelationKendall)
+		g.Expect(res.Coefficient).To(BeNumerically("~", -0.47140452079103173, 1e-12))
+		g.Expect(res.P).To(BeNumerically("~", 0.2827454599327748, 1e-9))
+		g.Expect(s1.Correlation(s2, CorrelationKendall)).To(Equal(res.Coefficient))
+	})
+
+	t.Run("Kendall against naive tau-b", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r := rand.New(rand.NewSource(1))
+		for _, n := range []int{2, 3, 10, 57, 200} {
+			x := make([]float64, n)
+			y := make([]float64, n)
+			for i := range x {
+				x[i] = float64(r.Intn(8))
+				y[i] = float64(r.Intn(8)) + x[i]/2
+			}
+			s1 := NewSampleWithValue(x, true)
+			s2 := NewSampleWithValue(y, true)
+			g.Expect(s1.Correlation(s2, CorrelationKendall)).To(BeNumerically("~", naiveKendall(x, y), 1e-12))
+		}
+	})
+
+	t.Run("Registered", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		sign := CorrelationType("TestSign")
+		t.Cleanup(func() {
+			unregisterCorrelation(sign)
+			unregisterCorrelation(CorrelationCustom)
+		})
+		RegisterCorrelation(sign, func(x, y []float64) float64 {
+			same := 0.0
+			for i := range x {
+				if (x[i] > 0) == (y[i] > 0) {
+					same++
+				}
+			}
+			return 2*same/float64(len(x)) - 1
+		})
+		RegisterCorrelation(CorrelationCustom, func(x, y []float64) float64 {
+			return float64(len(x))
+		})
+		g.Expect(Correlations()).To(ContainElement(sign))
+		g.Expect(Correlations()).To(ContainElement(CorrelationCustom))
+
+		s1 := NewSampleWithValue([]float64{1, -2, 3, 4}, true)
+		s2 := NewSampleWithValue([]float64{2, -1, -3, 1}, true)
+		g.Expect(s1.Correlation(s2, sign)).To(Equal(0.5))
+		g.Expect(s1.Correlation(s2, CorrelationCustom)).To(Equal(4.0))
+
+		res := s1.CorrelationTest(s2, sign)
+		g.Expect(res.Type).To(Equal(sign))
+		g.Expect(math.IsNaN(res.P)).To(BeTrue())
+
+		s3 := NewSampleWithValue([]float64{1}, true)
+		g.Expect(math.IsNaN(s1.Correlation(s3, sign))).To(BeTrue())
+
+		g.Expect(func() { RegisterCorrelation(sign, func(x, y []float64) float64 { return 0 }) }).To(Panic())
+		g.Expect(func() { RegisterCorrelation(CorrelationPearson, func(x, y []float64) float64 { return 0 }) }).To(Panic())
+		g.Expect(func() { RegisterCorrelation("TestNil", nil) }).To(Panic())
+		g.Expect(func() { s1.Correlation(s2, "TestUnknown") }).To(Panic())
+	})
+
+	t.Run("Pearson", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s1 := NewSampleWithValue([]float64{1, 2, 3, 4, 5}, true)
+		s2 := NewSampleWithValue([]float64{2, 4, 5, 4, 5}, true)
+		res := s1.CorrelationTest(s2, CorrelationPearson)
+		g.Expect(res.Coefficient).To(BeNumerically("~", 0.7745966692414834, 1e-12))
+		g.Expect(res.P).To(BeNumerically("~", 0.1240, 1e-4))
+	})
+
+}
+
+// naiveKendall computes Kendall's tau-b by comparing every pair.
+func naiveKendall(x, y []float64) float64 {
+	var nc, nd, tx, ty float64
+	for i := 0; i < len(x); i++ {
+		for j := i + 1; j < len(x); j++ {
+			dx, dy := x[i]-x[j], y[i]-y[j]
+			switch {
+			case dx == 0 && dy == 0:
+			case dx == 0:
+				tx++
+			case dy == 0:
+				ty++
+			case dx*dy > 0:
+				nc++
+			default:
+				nd++
+			}
+		}
+	}
+	denom := math.Sqrt((nc + nd + tx) * (nc + nd + ty))
+	if denom == 0 {
+		return 0
+	}
+	return (nc - nd) / denom
+}
+
+// unregisterCorrelation removes a registered correlation, so that tests can register it again.
+func unregisterCorrelation(name CorrelationType) {
+	correlationsMu.Lock()
+	defer correlationsMu.Unlock()
+	delete(correlations, name)
+}
@@ -0,0 +1,381 @@
+package gostats
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+// DefaultDDSketchMaxBuckets is enough to cover values from 1e-8 to 1e9 with a 1% relative accuracy without
+// collapsing any bucket.
+const DefaultDDSketchMaxBuckets = 2048
+
+const ddSketchEncodingVersion = 1
+
+var DDSketchPool = sync.Pool{
+	New: func() interface{} { return new(DDSketch) },
+}
+
+// DDSketch is a quantile sketch with relative accuracy guarantees (Masson, Rim & Lee, 2019): the value returned
+// by Quantile is within a relative error α of the exact quantile, whatever the distribution. This suits latency
+// style data better than rank-accurate sketches, as the tail values keep their precision.
+//
+// Values are counted in logarithmic buckets [γ^(i-1), γ^i] with γ = (1 + α) / (1 - α), negative values in a
+// mirrored set of buckets. When more than maxBuckets buckets are needed on a side, the buckets of the smallest
+// magnitudes are collapsed together, so the accuracy guarantee is kept for the largest magnitudes only.
+// Appending is O(1) amortized, and sketches with the same parameters can be merged.
+type DDSketch struct {
+	relativeAccuracy float64
+	maxBuckets       int
+	gamma            float64
+	logGamma         float64
+	minIndexable     float64
+	positives        ddSketchStore
+	negatives        ddSketchStore
+	// internal values
+	zeros float64
+	count float64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// ddSketchStore holds the counts of contiguous bucket indexes, counts[0] being the count of index offset.
+// counts is a window of buf starting at start, the other slots of buf being zero, so that the window can
+// be extended or moved without copying until it reaches the ends of buf.
+type ddSketchStore struct {
+	counts []float64
+	offset int
+	buf    []float64
+	start  int
+}
+
+// NewDDSketch returns an empty DDSketch with the given relative accuracy, using at most maxBuckets buckets for
+// positive values and as many for negative values. It panics unless 0 < relativeAccuracy < 1 and
+// maxBuckets >= 1.
+func NewDDSketch(relativeAccuracy float64, maxBuckets int) *DDSketch {
+	if !(relativeAccuracy > 0 && relativeAccuracy < 1) {
+		panic("DDSketch relative accuracy must be in (0, 1)")
+	}
+	if maxBuckets < 1 {
+		panic("DDSketch maxBuckets must be positive")
+	}
+	d := DDSketchPool.Get().(*DDSketch)
+	d.initEmptyValues(relativeAccuracy, maxBuckets)
+	return d
+}
+
+func (d *DDSketch) CleanPool() {
+	DDSketchPool.Put(d)
+}
+
+func (d *DDSketch) initEmptyValues(relativeAccuracy float64, maxBuckets int) {
+	d.relativeAccuracy = relativeAccuracy
+	d.maxBuckets = maxBuckets
+	d.gamma = (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	d.logGamma = math.Log(d.gamma)
+	// Below the smallest normal float, log would lose its precision.
+	d.minIndexable = 0x1p-1022 * d.gamma
+	d.positives.reset()
+	d.negatives.reset()
+	d.zeros = 0
+	d.count = 0
+	d.sum = 0
+	d.min = math.Inf(+1)
+	d.max = math.Inf(-1)
+}
+
+func (d *DDSketch) Append(value float64) {
+	d.AppendWeighted(value, 1)
+}
+
+func (d *DDSketch) AppendMany(values []float64) {
+	for _, value := range values {
+		d.AppendWeighted(value, 1)
+	}
+}
+
+// AppendWeighted adds a value with the given weight, which must be positive.
+func (d *DDSketch) AppendWeighted(value, weight float64) {
+	switch {
+	case value >= d.minIndexable:
+		d.positives.add(d.index(value), weight, d.maxBuckets)
+	case value <= -d.minIndexable:
+		d.negatives.add(d.index(-value), weight, d.maxBuckets)
+	default:
+		d.zeros = d.zeros + weight
+	}
+	d.count = d.count + weight
+	d.sum = d.sum + value*weight
+	if value < d.min {
+		d.min = value
+	}
+	if value > d.max {
+		d.max = value
+	}
+}
+
+// index returns the index of the bucket holding the positive value.
+func (d *DDSketch) index(value float64) int {
+	return int(math.Ceil(math.Log(value) / d.logGamma))
+}
+
+// value returns the value representing the bucket index, whose relative error is at most α for any value of
+// the bucket.
+func (d *DDSketch) value(index int) float64 {
+	return 2 * math.Exp(float64(index)*d.logGamma) / (1 + d.gamma)
+}
+
+// Merge adds the values of other to d. It fails with ErrIncompatibleSketches if the sketches do not have the
+// same relative accuracy and maximum number of buckets. other is not modified.
+func (d *DDSketch) Merge(other *DDSketch) error {
+	if d.relativeAccuracy != other.relativeAccuracy || d.maxBuckets != other.maxBuckets {
+		return ErrIncompatibleSketches
+	}
+	if other.count == 0 {
+		return nil
+	}
+	for i, c := range other.positives.counts {
+		if c != 0 {
+			d.positives.add(other.positives.offset+i, c, d.maxBuckets)
+		}
+	}
+	for i, c := range other.negatives.counts {
+		if c != 0 {
+			d.negatives.add(other.negatives.offset+i, c, d.maxBuckets)
+		}
+	}
+	d.zeros = d.zeros + other.zeros
+	d.count = d.count + other.count
+	d.sum = d.sum + other.sum
+	if other.min < d.min {
+		d.min = other.min
+	}
+	if other.max > d.max {
+		d.max = other.max
+	}
+	return nil
+}
+
+// RelativeAccuracy returns the relative accuracy α of the sketch.
+func (d *DDSketch) RelativeAccuracy() float64 {
+	return d.relativeAccuracy
+}
+
+// Count returns the total weight of the appended values.
+func (d *DDSketch) Count() float64 {
+	return d.count
+}
+
+// Sum returns the exact weighted sum of the appended values.
+func (d *DDSketch) Sum() float64 {
+	return d.sum
+}
+
+// Mean returns the exact weighted mean of the appended values.
+func (d *DDSketch) Mean() float64 {
+	return d.sum / d.count
+}
+
+// Bounds returns the exact minimum and maximum values appended to the sketch.
+func (d *DDSketch) Bounds() (min float64, max float64) {
+	if d.count == 0 {
+		return math.NaN(), math.NaN()
+	}
+	return d.min, d.max
+}
+
+// Quantile returns the value at rank q * (Count - 1), within the relative accuracy of the sketch, unless it
+// lies in collapsed buckets. The minimum and maximum are exact. It is NaN if the sketch is empty or q is not in
+// [0, 1].
+func (d *DDSketch) Quantile(q float64) float64 {
+	if d.count == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	if q == 0 {
+		return d.min
+	}
+	if q == 1 {
+		return d.max
+	}
+	rank := q * (d.count - 1)
+
+	value := d.max
+	n := 0.0
+	found := false
+	for i := len(d.negatives.counts) - 1; i >= 0 && !found; i-- {
+		n = n + d.negatives.counts[i]
+		if n > rank {
+			value, found = -d.value(d.negatives.offset+i), true
+		}
+	}
+	if !found {
+		n = n + d.zeros
+		if n > rank {
+			value, found = 0, true
+		}
+	}
+	for i := 0; i < len(d.positives.counts) && !found; i++ {
+		n = n + d.positives.counts[i]
+		if n > rank {
+			value, found = d.value(d.positives.offset+i), true
+		}
+	}
+
+	// The exact bounds are always more accurate than the buckets.
+	if value < d.min {
+		return d.min
+	}
+	if value > d.max {
+		return d.max
+	}
+	return value
+}
+
+// reset empties the store, keeping buf.
+func (s *ddSketchStore) reset() {
+	for i := range s.counts {
+		s.counts[i] = 0
+	}
+	s.start = len(s.buf) / 2
+	s.counts = s.buf[s.start:s.start]
+}
+
+func (s *ddSketchStore) add(index int, weight float64, maxBuckets int) {
+	if len(s.counts) == 0 {
+		s.offset = index
+		s.reshape(index, index)
+	}
+	hi := s.offset + len(s.counts) - 1
+	if index > hi {
+		lo := s.offset
+		if index-lo+1 > maxBuckets {
+			lo = index - maxBuckets + 1
+		}
+		s.reshape(lo, index)
+	} else if index < s.offset {
+		lo := index
+		if hi-lo+1 > maxBuckets {
+			lo = hi - maxBuckets + 1
+		}
+		if lo < s.offset {
+			s.reshape(lo, hi)
+		}
+		if index < lo {
+			index = lo
+		}
+	}
+	s.counts[index-s.offset] += weight
+}
+
+// reshape makes the store cover the indexes [lo, hi], collapsing the counts of the indexes below lo into lo.
+// The window moves within buf, which is only reallocated, or recentered, when the window would leave it: buf is
+// then made 3 times as large as the window, so that this costs O(1) amortized per index added to the window.
+func (s *ddSketchStore) reshape(lo, hi int) {
+	n := hi - lo + 1
+	end := s.start + len(s.counts)
+	newStart := s.start + lo - s.offset
+
+	// The slots of the indexes below lo are cleared, and the slots [from, end) are kept.
+	collapsed := 0.0
+	from := s.start
+	for ; from < newStart && from < end; from++ {
+		collapsed += s.buf[from]
+		s.buf[from] = 0
+	}
+
+	if newStart < 0 || newStart+n > len(s.buf) {
+		// The kept slots move to the middle third of buf. When lo is above the whole window, nothing is kept.
+		target := n
+		if from < end {
+			target += from - newStart
+		}
+		if len(s.buf) < 3*n {
+			buf := make([]float64, 3*n)
+			copy(buf[target:], s.buf[from:end])
+			s.buf = buf
+		} else {
+			kept := copy(s.buf[target:], s.buf[from:end])
+			for i := range s.buf[:target] {
+				s.buf[i] = 0
+			}
+			for i := range s.buf[target+kept:] {
+				s.buf[target+kept+i] = 0
+			}
+		}
+		newStart = n
+	}
+
+	s.buf[newStart] += collapsed
+	s.start = newStart
+	s.counts = s.buf[newStart : newStart+n]
+	s.offset = lo
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (d *DDSketch) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 1+8+4+5*8, 1+8+4+5*8+2*8+8*(len(d.positives.counts)+len(d.negatives.counts)))
+	data[0] = ddSketchEncodingVersion
+	binary.BigEndian.PutUint64(data[1:], math.Float64bits(d.relativeAccuracy))
+	binary.BigEndian.PutUint32(data[9:], uint32(d.maxBuckets))
+	for i, v := range []float64{d.zeros, d.count, d.sum, d.min, d.max} {
+		binary.BigEndian.PutUint64(data[13+8*i:], math.Float64bits(v))
+	}
+	for _, store := range []*ddSketchStore{&d.positives, &d.negatives} {
+		var header [8]byte
+		binary.BigEndian.PutUint32(header[:], uint32(int32(store.offset)))
+		binary.BigEndian.PutUint32(header[4:], uint32(len(store.counts)))
+		data = append(data, header[:]...)
+		for _, c := range store.counts {
+			var b [8]byte
+			binary.BigEndian.PutUint64(b[:], math.Float64bits(c))
+			data = append(data, b[:]...)
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (d *DDSketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 53 {
+		return ErrInvalidEncoding
+	}
+	if data[0] != ddSketchEncodingVersion {
+		return ErrUnsupportedVersion
+	}
+	relativeAccuracy := math.Float64frombits(binary.BigEndian.Uint64(data[1:]))
+	maxBuckets := int(binary.BigEndian.Uint32(data[9:]))
+	if !(relativeAccuracy > 0 && relativeAccuracy < 1) || maxBuckets < 1 {
+		return ErrInvalidEncoding
+	}
+
+	d.initEmptyValues(relativeAccuracy, maxBuckets)
+	values := []*float64{&d.zeros, &d.count, &d.sum, &d.min, &d.max}
+	for i, v := range values {
+		*v = math.Float64frombits(binary.BigEndian.Uint64(data[13+8*i:]))
+	}
+	data = data[53:]
+	for _, store := range []*ddSketchStore{&d.positives, &d.negatives} {
+		if len(data) < 8 {
+			return ErrInvalidEncoding
+		}
+		offset := int(int32(binary.BigEndian.Uint32(data)))
+		n := int(binary.BigEndian.Uint32(data[4:]))
+		data = data[8:]
+		if n > maxBuckets || len(data) < 8*n {
+			return ErrInvalidEncoding
+		}
+		store.offset = offset
+		store.buf = make([]float64, n)
+		store.start = 0
+		store.counts = store.buf
+		for i := range store.counts {
+			store.counts[i] = math.Float64frombits(binary.BigEndian.Uint64(data[8*i:]))
+		}
+		data = data[8*n:]
+	}
+	if len(data) != 0 {
+		return ErrInvalidEncoding
+	}
+	return nil
+}
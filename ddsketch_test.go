@@ -0,0 +1,207 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestDDSketch(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 100000)
+	for i := range values {
+		// latency like: log-normal, with some negative values
+		values[i] = math.Exp(2 * r.NormFloat64())
+		if i%10 == 0 {
+			values[i] = -values[i]
+		}
+		if i%100 == 0 {
+			values[i] = 0
+		}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	exact := func(q float64) float64 {
+		return sorted[int(q*float64(len(sorted)-1))]
+	}
+
+	t.Run("Small", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewDDSketch(0.01, DefaultDDSketchMaxBuckets)
+		g.Expect(math.IsNaN(d.Quantile(0.5))).To(BeTrue())
+		min, _ := d.Bounds()
+		g.Expect(math.IsNaN(min)).To(BeTrue())
+		d.AppendMany([]float64{-3, 0, 1, 2, 10})
+		g.Expect(d.Count()).To(Equal(5.0))
+		g.Expect(d.Sum()).To(Equal(10.0))
+		g.Expect(d.Mean()).To(Equal(2.0))
+		g.Expect(d.Quantile(0)).To(Equal(-3.0))
+		g.Expect(d.Quantile(0.25)).To(Equal(0.0))
+		g.Expect(d.Quantile(0.5)).To(BeNumerically("~", 1, 0.01))
+		g.Expect(d.Quantile(0.75)).To(BeNumerically("~", 2, 0.02))
+		g.Expect(d.Quantile(1)).To(Equal(10.0))
+		g.Expect(math.IsNaN(d.Quantile(1.5))).To(BeTrue())
+		d.CleanPool()
+	})
+
+	t.Run("Relative accuracy", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewDDSketch(0.01, DefaultDDSketchMaxBuckets)
+		d.AppendMany(values)
+		g.Expect(d.Count()).To(Equal(float64(len(values))))
+		for _, q := range []float64{0, 0.001, 0.01, 0.05, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999, 1} {
+			x := exact(q)
+			g.Expect(d.Quantile(q)).To(BeNumerically("~", x, 0.01*math.Abs(x)+1e-12), "q=%v", q)
+		}
+		min, max := d.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{sorted[0], sorted[len(sorted)-1]}))
+		d.CleanPool()
+	})
+
+	t.Run("Collapsing", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewDDSketch(0.01, 200)
+		d.AppendMany(values)
+		g.Expect(len(d.positives.counts)).To(BeNumerically("<=", 200))
+		g.Expect(len(d.negatives.counts)).To(BeNumerically("<=", 200))
+		g.Expect(d.Count()).To(Equal(float64(len(values))))
+		// the largest magnitudes keep their accuracy
+		for _, q := range []float64{0.999, 0.9999} {
+			x := exact(q)
+			g.Expect(d.Quantile(q)).To(BeNumerically("~", x, 0.01*x))
+		}
+		x := exact(0.0001)
+		g.Expect(d.Quantile(0.0001)).To(BeNumerically("~", x, 0.01*math.Abs(x)))
+		d.CleanPool()
+	})
+
+	t.Run("Drifting stream", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewDDSketch(0.01, 50)
+		// reference keeps every index, collapsing the ones below the top 50 on the way.
+		reference := map[int]float64{}
+		top := math.MinInt32
+		next := func(i int) float64 { return math.Pow(1.01, float64(i%3000)) * float64(1+i/3000) }
+		for i := 0; i < 6000; i++ {
+			value := next(i)
+			d.Append(value)
+			index := d.index(value)
+			if index > top {
+				top = index
+			}
+			if index < top-49 {
+				index = top - 49
+			}
+			reference[index]++
+			for k, c := range reference {
+				if k < top-49 {
+					delete(reference, k)
+					reference[top-49] += c
+				}
+			}
+		}
+		g.Expect(len(d.positives.counts)).To(BeNumerically("<=", 50))
+		for i, c := range d.positives.counts {
+			g.Expect(c).To(Equal(reference[d.positives.offset+i]), "index %v", d.positives.offset+i)
+		}
+		g.Expect(d.positives.offset + len(d.positives.counts) - 1).To(Equal(top))
+
+		// Once the buffer is large enough, moving the window does not allocate.
+		i := 6000
+		allocs := testing.AllocsPerRun(1000, func() {
+			d.Append(math.Pow(1.01, float64(i)))
+			i++
+		})
+		g.Expect(allocs).To(Equal(0.0))
+
+		d.CleanPool()
+		d = NewDDSketch(0.01, 50)
+		d.AppendMany([]float64{1, 2, 3})
+		g.Expect(d.Count()).To(Equal(3.0))
+		g.Expect(d.Quantile(0.5)).To(BeNumerically("~", 2, 0.02))
+		d.CleanPool()
+	})
+
+	t.Run("Far jump", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, sign := range []float64{1, -1} {
+			d := NewDDSketch(0.01, 10)
+			for i := 0; i < 40; i++ {
+				d.Append(sign * math.Pow(1.0202, float64(i)))
+			}
+			// The new index is far above the window, so every bucket collapses into its lowest one.
+			g.Expect(func() { d.Append(sign * 1e12) }).NotTo(Panic())
+			store := &d.positives
+			if sign < 0 {
+				store = &d.negatives
+			}
+			g.Expect(store.counts).To(HaveLen(10))
+			g.Expect(store.offset + 9).To(Equal(d.index(1e12)))
+			g.Expect(store.counts[0]).To(Equal(40.0))
+			g.Expect(store.counts[9]).To(Equal(1.0))
+			g.Expect(d.Count()).To(Equal(41.0))
+
+			d.Append(sign * 1e13)
+			g.Expect(store.counts[0]).To(Equal(41.0))
+			g.Expect(store.counts[len(store.counts)-1]).To(Equal(1.0))
+			g.Expect(d.Count()).To(Equal(42.0))
+			d.CleanPool()
+		}
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewDDSketch(0.01, DefaultDDSketchMaxBuckets)
+		whole := NewDDSketch(0.01, DefaultDDSketchMaxBuckets)
+		whole.AppendMany(values)
+		for i := 0; i < len(values); i += 10000 {
+			part := NewDDSketch(0.01, DefaultDDSketchMaxBuckets)
+			part.AppendMany(values[i : i+10000])
+			g.Expect(d.Merge(part)).To(Succeed())
+			part.CleanPool()
+		}
+		g.Expect(d.Count()).To(Equal(whole.Count()))
+		g.Expect(d.Sum()).To(BeNumerically("~", whole.Sum(), 1e-6))
+		for _, q := range []float64{0, 0.01, 0.5, 0.99, 1} {
+			g.Expect(d.Quantile(q)).To(Equal(whole.Quantile(q)))
+		}
+
+		other := NewDDSketch(0.02, DefaultDDSketchMaxBuckets)
+		g.Expect(d.Merge(other)).To(Equal(ErrIncompatibleSketches))
+		other.CleanPool()
+		whole.CleanPool()
+		d.CleanPool()
+	})
+
+	t.Run("Binary encoding", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewDDSketch(0.01, 500)
+		d.AppendMany(values)
+		data, err := d.MarshalBinary()
+		g.Expect(err).NotTo(HaveOccurred())
+
+		decoded := NewDDSketch(0.05, 10)
+		g.Expect(decoded.UnmarshalBinary(data)).To(Succeed())
+		g.Expect(decoded.RelativeAccuracy()).To(Equal(0.01))
+		g.Expect(decoded.Count()).To(Equal(d.Count()))
+		for _, q := range []float64{0, 0.01, 0.5, 0.99, 1} {
+			g.Expect(decoded.Quantile(q)).To(Equal(d.Quantile(q)))
+		}
+
+		g.Expect(decoded.UnmarshalBinary(data[:len(data)-1])).To(Equal(ErrInvalidEncoding))
+		data[0] = 2
+		g.Expect(decoded.UnmarshalBinary(data)).To(Equal(ErrUnsupportedVersion))
+		decoded.CleanPool()
+		d.CleanPool()
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(func() { NewDDSketch(0, 10) }).To(Panic())
+		g.Expect(func() { NewDDSketch(1, 10) }).To(Panic())
+		g.Expect(func() { NewDDSketch(0.01, 0) }).To(Panic())
+	})
+}
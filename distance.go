@@ -0,0 +1,292 @@
+package gostats
+
+import (
+	"math"
+)
+
+type DistanceType int
+
+const (
+	// DistanceChebyshev is the greatest absolute difference: max |x - y|
+	DistanceChebyshev DistanceType = 1
+	// DistanceEuclidean is the straight-line distance: √Σ(x - y)²
+	DistanceEuclidean DistanceType = 2
+	// DistanceManhattan is the sum of absolute differences: Σ|x - y|, or their
+	// mean with NormalizeLength, which Distance always uses.
+	DistanceManhattan DistanceType = 3
+	// DistanceMinkowski generalizes Manhattan (P = 1), Euclidean (P = 2) and
+	// Chebyshev (P = +Inf): (Σ|x - y|ᴾ)^(1/P)
+	DistanceMinkowski DistanceType = 4
+	// DistanceCanberra is a weighted Manhattan distance: Σ|x - y| / (|x| + |y|)
+	DistanceCanberra DistanceType = 5
+	// DistanceCosine is one minus the cosine similarity: 1 - x·y / (‖x‖‖y‖).
+	// It is undefined when x or y is all zeros.
+	DistanceCosine DistanceType = 6
+	// DistanceBrayCurtis is Σ|x - y| / Σ|x + y|, in [0, 1] for non-negative values.
+	// It is 0 for equal Samples, and undefined when x = -y otherwise.
+	DistanceBrayCurtis DistanceType = 7
+	// DistanceDTW is the dynamic time warping distance, using |x - y| as
+	// local cost. It is the only distance accepting Samples of different lengths.
+	DistanceDTW DistanceType = 8
+)
+
+// DistanceNormalization is a set of flags controlling how a distance is
+// normalized. The zero value computes the textbook distance.
+type DistanceNormalization int
+
+const (
+	// NormalizeLength divides the accumulated sum by the number of points
+	// before taking the root, so that distances between Samples of
+	// different lengths are comparable: Euclidean becomes the root mean
+	// square difference and Manhattan the mean absolute difference. DTW is
+	// divided by the length of the warping path. Chebyshev, Cosine and
+	// Bray-Curtis are not affected.
+	NormalizeLength DistanceNormalization = 1 << iota
+	// NormalizeZScore standardizes both Samples to a zero mean and a unit
+	// population standard deviation before computing the distance, so that
+	// only the shapes of the series are compared. A constant Sample
+	// standardizes to zeros.
+	NormalizeZScore
+)
+
+// DistanceOptions parameterizes DistanceWithOptions.
+type DistanceOptions struct {
+	// Normalization is applied to any distance type.
+	Normalization DistanceNormalization
+	// P is the order of DistanceMinkowski. It must be >= 1, math.Inf(1)
+	// gives the Chebyshev distance.
+	P float64
+	// Window is the Sakoe-Chiba band radius of DistanceDTW: values more than
+	// Window points apart are never matched. Zero means no constraint. It
+	// is widened to the difference of lengths when needed.
+	Window int
+}
+
+// Calculate the Distance ( Chebyshev / Euclidean / Manhattan / Canberra / Cosine / BrayCurtis / DTW )
+// with another Sample, without normalization, apart from Manhattan which is the mean absolute difference
+// as it always has been. DistanceMinkowski needs an order, see DistanceWithOptions.
+// It panics on misuse, see DistanceE.
+func (s *Sample) Distance(data *Sample, distanceType DistanceType) float64 {
+	return nanOrPanic(s.DistanceE(data, distanceType))
+}
+
+// DistanceE is like Distance, but returns an error instead of panicking.
+func (s *Sample) DistanceE(data *Sample, distanceType DistanceType) (float64, error) {
+	var options DistanceOptions
+	if distanceType == DistanceManhattan {
+		options.Normalization = NormalizeLength
+	}
+	return s.DistanceWithOptionsE(data, distanceType, options)
+}
+
+// DistanceWithOptions is like Distance, with the normalization and the
+// parameters of the distance given by options.
+func (s *Sample) DistanceWithOptions(data *Sample, distanceType DistanceType, options DistanceOptions) float64 {
+	return nanOrPanic(s.DistanceWithOptionsE(data, distanceType, options))
+}
+
+// DistanceWithOptionsE is like DistanceWithOptions, but returns an error
+// instead of panicking. Undefined Cosine and Bray-Curtis distances give
+// ErrZeroVector, and NaN without the E suffix.
+func (s *Sample) DistanceWithOptionsE(data *Sample, distanceType DistanceType, options DistanceOptions) (float64, error) {
+	if distanceType == DistanceDTW {
+		if err := s.validateOriginals(data); err != nil {
+			return math.NaN(), err
+		}
+	} else if err := s.validateLengths(data); err != nil {
+		return math.NaN(), err
+	} else if !s.withOriginal || !data.withOriginal {
+		return math.NaN(), ErrNoOriginal
+	}
+
+	x, y := s.original, data.original
+	if options.Normalization&NormalizeZScore != 0 {
+		x, y = zScore(x), zScore(y)
+	}
+	byLength := options.Normalization&NormalizeLength != 0
+	n := float64(len(x))
+
+	switch distanceType {
+	case DistanceChebyshev:
+		return distanceChebyshev(x, y), nil
+	case DistanceEuclidean:
+		return distanceMinkowski(x, y, 2, byLength), nil
+	case DistanceManhattan:
+		return distanceMinkowski(x, y, 1, byLength), nil
+	case DistanceMinkowski:
+		if !(options.P >= 1) {
+			return math.NaN(), ErrMinkowskiOrder
+		}
+		if math.IsInf(options.P, 1) {
+			return distanceChebyshev(x, y), nil
+		}
+		return distanceMinkowski(x, y, options.P, byLength), nil
+	case DistanceCanberra:
+		distance := 0.0
+		for i := range x {
+			if denom := math.Abs(x[i]) + math.Abs(y[i]); denom != 0 {
+				distance += math.Abs(x[i]-y[i]) / denom
+			}
+		}
+		if byLength {
+			distance /= n
+		}
+		return distance, nil
+	case DistanceCosine:
+		dot, nx, ny := 0.0, 0.0, 0.0
+		for i := range x {
+			dot += x[i] * y[i]
+			nx += x[i] * x[i]
+			ny += y[i] * y[i]
+		}
+		if nx == 0 || ny == 0 {
+			return math.NaN(), ErrZeroVector
+		}
+		return 1 - dot/math.Sqrt(nx*ny), nil
+	case DistanceBrayCurtis:
+		diff, sum := 0.0, 0.0
+		for i := range x {
+			diff += math.Abs(x[i] - y[i])
+			sum += math.Abs(x[i] + y[i])
+		}
+		if diff == 0 {
+			return 0, nil
+		}
+		if sum == 0 {
+			return math.NaN(), ErrZeroVector
+		}
+		return diff / sum, nil
+	case DistanceDTW:
+		return distanceDTW(x, y, options.Window, byLength), nil
+	default:
+		return math.NaN(), ErrUnknownDistance
+	}
+}
+
+// validateOriginals checks that s and data both have non empty original
+// values, without requiring the same length.
+func (s *Sample) validateOriginals(data *Sample) error {
+	if !s.withOriginal || !data.withOriginal {
+		return ErrNoOriginal
+	}
+	if s.nb == 0 || data.Len() == 0 {
+		return ErrSampleSize
+	}
+	return nil
+}
+
+func distanceChebyshev(x, y []float64) float64 {
+	distance := 0.0
+	for i := range x {
+		if d := math.Abs(x[i] - y[i]); d > distance {
+			distance = d
+		}
+	}
+	return distance
+}
+
+// distanceMinkowski computes (Σ|x - y|ᵖ)^(1/p), averaging the sum first
+// when byLength is set.
+func distanceMinkowski(x, y []float64, p float64, byLength bool) float64 {
+	sum := 0.0
+	for i := range x {
+		d := math.Abs(x[i] - y[i])
+		switch p {
+		case 1:
+			sum += d
+		case 2:
+			sum += d * d
+		default:
+			sum += math.Pow(d, p)
+		}
+	}
+	if byLength {
+		sum /= float64(len(x))
+	}
+	switch p {
+	case 1:
+		return sum
+	case 2:
+		return math.Sqrt(sum)
+	default:
+		return math.Pow(sum, 1/p)
+	}
+}
+
+// distanceDTW computes the dynamic time warping distance between x and y
+// in O(len(x) * window) time and O(len(y)) memory.
+func distanceDTW(x, y []float64, window int, byLength bool) float64 {
+	n, m := len(x), len(y)
+	if window <= 0 {
+		window = n + m
+	}
+	if d := n - m; d > window {
+		window = d
+	} else if -d > window {
+		window = -d
+	}
+
+	inf := math.Inf(1)
+	// cost[j] is the cheapest path reaching (i, j), steps[j] its length.
+	prevCost, cost := make([]float64, m+1), make([]float64, m+1)
+	prevSteps, steps := make([]int, m+1), make([]int, m+1)
+	for j := range prevCost {
+		prevCost[j] = inf
+	}
+	prevCost[0] = 0
+
+	for i := 1; i <= n; i++ {
+		for j := range cost {
+			cost[j] = inf
+		}
+		lo, hi := i-window, i+window
+		if lo < 1 {
+			lo = 1
+		}
+		if hi > m {
+			hi = m
+		}
+		for j := lo; j <= hi; j++ {
+			best, bestSteps := prevCost[j-1], prevSteps[j-1]
+			if prevCost[j] < best {
+				best, bestSteps = prevCost[j], prevSteps[j]
+			}
+			if cost[j-1] < best {
+				best, bestSteps = cost[j-1], steps[j-1]
+			}
+			cost[j] = best + math.Abs(x[i-1]-y[j-1])
+			steps[j] = bestSteps + 1
+		}
+		prevCost, cost = cost, prevCost
+		prevSteps, steps = steps, prevSteps
+	}
+
+	if byLength {
+		return prevCost[m] / float64(prevSteps[m])
+	}
+	return prevCost[m]
+}
+
+// zScore returns a standardized copy of xs, using the population standard
+// deviation.
+func zScore(xs []float64) []float64 {
+	mean := 0.0
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	variance := 0.0
+	for _, x := range xs {
+		variance += (x - mean) * (x - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(xs)))
+
+	zs := make([]float64, len(xs))
+	if stdDev == 0 {
+		return zs
+	}
+	for i, x := range xs {
+		zs[i] = (x - mean) / stdDev
+	}
+	return zs
+}
@@ -0,0 +1,98 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+
+	x := NewSampleWithValue([]float64{1, 2, 3}, true)
+	y := NewSampleWithValue([]float64{2, 4, 1}, true)
+
+	t.Run("Textbook", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, c := range []struct {
+			distanceType DistanceType
+			out          float64
+		}{
+			{DistanceChebyshev, 2},
+			{DistanceEuclidean, 3},
+			{DistanceManhattan, 5.0 / 3},
+			{DistanceCanberra, 7.0 / 6},
+			{DistanceCosine, 1 - 13/math.Sqrt(294)},
+			{DistanceBrayCurtis, 5.0 / 13},
+		} {
+			g.Expect(x.Distance(y, c.distanceType)).To(BeNumerically("~", c.out, 1e-12))
+			g.Expect(y.Distance(x, c.distanceType)).To(BeNumerically("~", c.out, 1e-12))
+		}
+	})
+
+	t.Run("Manhattan", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		// The textbook sum, like Minkowski with P = 1, Distance keeping the mean.
+		g.Expect(x.DistanceWithOptions(y, DistanceManhattan, DistanceOptions{})).To(Equal(5.0))
+		g.Expect(x.DistanceWithOptions(y, DistanceMinkowski, DistanceOptions{P: 1})).To(Equal(5.0))
+		g.Expect(x.DistanceWithOptions(y, DistanceManhattan, DistanceOptions{Normalization: NormalizeLength})).To(BeNumerically("~", 5.0/3, 1e-12))
+		g.Expect(x.DistanceE(y, DistanceManhattan)).To(BeNumerically("~", 5.0/3, 1e-12))
+	})
+
+	t.Run("Minkowski", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(x.DistanceWithOptions(y, DistanceMinkowski, DistanceOptions{P: 1})).To(Equal(5.0))
+		g.Expect(x.DistanceWithOptions(y, DistanceMinkowski, DistanceOptions{P: 2})).To(Equal(3.0))
+		g.Expect(x.DistanceWithOptions(y, DistanceMinkowski, DistanceOptions{P: 3})).To(BeNumerically("~", math.Cbrt(17), 1e-12))
+		g.Expect(x.DistanceWithOptions(y, DistanceMinkowski, DistanceOptions{P: math.Inf(1)})).To(Equal(2.0))
+		_, err := x.DistanceE(y, DistanceMinkowski)
+		g.Expect(err).To(Equal(ErrMinkowskiOrder))
+	})
+
+	t.Run("NormalizeLength", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		options := DistanceOptions{Normalization: NormalizeLength}
+		g.Expect(x.DistanceWithOptions(y, DistanceEuclidean, options)).To(BeNumerically("~", math.Sqrt(3), 1e-12))
+		g.Expect(x.DistanceWithOptions(y, DistanceManhattan, options)).To(BeNumerically("~", 5.0/3, 1e-12))
+		g.Expect(x.DistanceWithOptions(y, DistanceChebyshev, options)).To(Equal(2.0))
+	})
+
+	t.Run("NormalizeZScore", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		scaled := NewSampleWithValue([]float64{10, 20, 30}, true)
+		options := DistanceOptions{Normalization: NormalizeZScore}
+		g.Expect(x.DistanceWithOptions(scaled, DistanceEuclidean, options)).To(BeNumerically("~", 0, 1e-12))
+		g.Expect(x.Distance(scaled, DistanceEuclidean)).To(BeNumerically(">", 0))
+	})
+
+	t.Run("DTW", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		reversed := NewSampleWithValue([]float64{3, 2, 1}, true)
+		g.Expect(x.Distance(reversed, DistanceDTW)).To(Equal(4.0))
+
+		shifted := NewSampleWithValue([]float64{0, 1, 1, 2, 3, 3}, true)
+		base := NewSampleWithValue([]float64{0, 1, 2, 3}, true)
+		g.Expect(base.Distance(shifted, DistanceDTW)).To(Equal(0.0))
+		g.Expect(math.IsNaN(base.Distance(shifted, DistanceEuclidean))).To(BeTrue())
+
+		step := NewSampleWithValue([]float64{0, 0, 0, 1}, true)
+		late := NewSampleWithValue([]float64{0, 1, 1, 1}, true)
+		g.Expect(step.Distance(late, DistanceDTW)).To(Equal(0.0))
+		g.Expect(step.DistanceWithOptions(late, DistanceDTW, DistanceOptions{Window: 1})).To(Equal(1.0))
+		g.Expect(step.DistanceWithOptions(late, DistanceDTW, DistanceOptions{Window: 1, Normalization: NormalizeLength})).To(Equal(0.2))
+	})
+
+	t.Run("Degenerate", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		zeros := NewSampleWithValue([]float64{0, 0, 0}, true)
+		g.Expect(math.IsNaN(x.Distance(zeros, DistanceCosine))).To(BeTrue())
+		_, err := x.DistanceE(zeros, DistanceCosine)
+		g.Expect(err).To(Equal(ErrZeroVector))
+		g.Expect(zeros.Distance(zeros, DistanceBrayCurtis)).To(Equal(0.0))
+		opposite := NewSampleWithValue([]float64{-1, -2, -3}, true)
+		g.Expect(math.IsNaN(x.Distance(opposite, DistanceBrayCurtis))).To(BeTrue())
+		_, err = x.DistanceE(opposite, DistanceBrayCurtis)
+		g.Expect(err).To(Equal(ErrZeroVector))
+		g.Expect(zeros.Distance(zeros, DistanceCanberra)).To(Equal(0.0))
+	})
+
+}
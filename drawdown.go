@@ -0,0 +1,266 @@
+package gostats
+
+import (
+	"sync"
+)
+
+// DrawdownMode tells how the equity curve is built from the values of a series.
+type DrawdownMode int
+
+const (
+	// DrawdownAdditive considers the values as profits & losses: the equity is their cumulative sum, starting
+	// at 0, and drawdowns are in the unit of the values.
+	DrawdownAdditive DrawdownMode = iota + 1
+	// DrawdownCompounded considers the values as returns: the equity is the product of (1 + value), starting
+	// at 1, and drawdowns are fractions of the peak equity.
+	DrawdownCompounded
+)
+
+// DrawdownEpisode is a period during which the equity is below its previous peak. Indexes are the indexes of
+// the values, -1 standing for the initial equity.
+type DrawdownEpisode struct {
+	// Peak is the index of the peak before the drawdown.
+	Peak int
+	// Trough is the index of the lowest equity of the drawdown.
+	Trough int
+	// Recovery is the index of the first value getting back to the peak, or -1 if it has not been recovered.
+	Recovery int
+	// Depth is the drop from the peak to the trough, positive.
+	Depth float64
+	// Duration is the number of values below the peak, from the value after the peak to the value before the
+	// recovery, or to the last value if it has not been recovered.
+	Duration int
+	// TimeToRecovery is the number of values from the trough to the recovery, or -1 if it has not been
+	// recovered.
+	TimeToRecovery int
+}
+
+// DrawdownReport sums up the drawdowns of a series. Durations are numbers of values below the peak, see
+// DrawdownEpisode.Duration.
+type DrawdownReport struct {
+	MaxDrawdown float64
+	// MaxDrawdownDuration is the largest Duration of the episodes.
+	MaxDrawdownDuration     int
+	CurrentDrawdown         float64
+	CurrentDrawdownDuration int
+	// Episodes are the drawdowns, in chronological order, the last one possibly being in progress.
+	Episodes []DrawdownEpisode
+}
+
+// drawdownTracker follows the drawdowns of an equity curve in O(1) per value.
+type drawdownTracker struct {
+	mode        DrawdownMode
+	equity      float64
+	peak        float64
+	peakIndex   int
+	trough      float64
+	troughIndex int
+	count       int
+	inDrawdown  bool
+	maxDrawdown float64
+	maxDuration int
+}
+
+// validateDrawdownMode checks that the mode is one of the DrawdownMode constants.
+func validateDrawdownMode(mode DrawdownMode) error {
+	switch mode {
+	case DrawdownAdditive, DrawdownCompounded:
+		return nil
+	}
+	return ErrUnknownDrawdownMode
+}
+
+func (d *drawdownTracker) init(mode DrawdownMode) {
+	if err := validateDrawdownMode(mode); err != nil {
+		panic(err)
+	}
+	*d = drawdownTracker{mode: mode, peakIndex: -1}
+	if mode == DrawdownCompounded {
+		d.equity = 1
+	}
+	d.peak = d.equity
+}
+
+// depth returns the drop from the peak to equity.
+func (d *drawdownTracker) depth(equity float64) float64 {
+	if d.mode == DrawdownCompounded {
+		return 1 - equity/d.peak
+	}
+	return d.peak - equity
+}
+
+// append adds a value, and returns the episode it ends, if any.
+func (d *drawdownTracker) append(value float64) (DrawdownEpisode, bool) {
+	index := d.count
+	d.count++
+	if d.mode == DrawdownCompounded {
+		d.equity = d.equity * (1 + value)
+	} else {
+		d.equity = d.equity + value
+	}
+
+	if d.equity >= d.peak {
+		var episode DrawdownEpisode
+		recovered := d.inDrawdown
+		if recovered {
+			episode = d.episode(index)
+			d.inDrawdown = false
+		}
+		d.peak = d.equity
+		d.peakIndex = index
+		return episode, recovered
+	}
+
+	if !d.inDrawdown || d.equity < d.trough {
+		d.trough = d.equity
+		d.troughIndex = index
+	}
+	d.inDrawdown = true
+	if depth := d.depth(d.equity); depth > d.maxDrawdown {
+		d.maxDrawdown = depth
+	}
+	if duration := index - d.peakIndex; duration > d.maxDuration {
+		d.maxDuration = duration
+	}
+	return DrawdownEpisode{}, false
+}
+
+// episode returns the current episode, recovered at the given index, or -1.
+func (d *drawdownTracker) episode(recovery int) DrawdownEpisode {
+	episode := DrawdownEpisode{
+		Peak:           d.peakIndex,
+		Trough:         d.troughIndex,
+		Recovery:       recovery,
+		Depth:          d.depth(d.trough),
+		Duration:       recovery - 1 - d.peakIndex,
+		TimeToRecovery: recovery - d.troughIndex,
+	}
+	if recovery < 0 {
+		episode.Duration = d.count - 1 - d.peakIndex
+		episode.TimeToRecovery = -1
+	}
+	return episode
+}
+
+func (d *drawdownTracker) current() (float64, int) {
+	if !d.inDrawdown {
+		return 0, 0
+	}
+	return d.depth(d.equity), d.count - 1 - d.peakIndex
+}
+
+// Drawdown returns the drawdowns of the Sample, its values being taken in their original order.
+// It panics if the Sample has no original values or the mode is unknown, see DrawdownE.
+func (s *Sample) Drawdown(mode DrawdownMode) *DrawdownReport {
+	report, err := s.DrawdownE(mode)
+	if err != nil {
+		panic(err)
+	}
+	return report
+}
+
+// DrawdownE is like Drawdown, but returns an error instead of panicking.
+func (s *Sample) DrawdownE(mode DrawdownMode) (*DrawdownReport, error) {
+	if err := validateDrawdownMode(mode); err != nil {
+		return nil, err
+	}
+	if !s.withOriginal {
+		return nil, ErrNoOriginal
+	}
+	var d drawdownTracker
+	d.init(mode)
+	report := &DrawdownReport{}
+	for _, value := range s.original {
+		if episode, ok := d.append(value); ok {
+			report.Episodes = append(report.Episodes, episode)
+		}
+	}
+	if d.inDrawdown {
+		report.Episodes = append(report.Episodes, d.episode(-1))
+	}
+	report.MaxDrawdown = d.maxDrawdown
+	report.MaxDrawdownDuration = d.maxDuration
+	report.CurrentDrawdown, report.CurrentDrawdownDuration = d.current()
+	return report, nil
+}
+
+var DrawdownStreamPool = sync.Pool{
+	New: func() interface{} { return new(DrawdownStream) },
+}
+
+// DrawdownStream follows the drawdowns of a stream of profits & losses or returns in O(1) per value, without
+// keeping the values. See Sample.Drawdown for the full list of episodes.
+type DrawdownStream struct {
+	tracker drawdownTracker
+	last    DrawdownEpisode
+	// recovered tells whether last is set
+	recovered bool
+}
+
+// NewDrawdownStream returns an empty DrawdownStream. It panics if the mode is unknown.
+func NewDrawdownStream(mode DrawdownMode) *DrawdownStream {
+	d := DrawdownStreamPool.Get().(*DrawdownStream)
+	d.tracker.init(mode)
+	d.last = DrawdownEpisode{}
+	d.recovered = false
+	return d
+}
+
+func (d *DrawdownStream) CleanPool() {
+	DrawdownStreamPool.Put(d)
+}
+
+func (d *DrawdownStream) AppendMany(values []float64) {
+	for _, value := range values {
+		d.Append(value)
+	}
+}
+
+// Append adds a value. It returns the drawdown episode the value recovers from, if any.
+func (d *DrawdownStream) Append(value float64) (DrawdownEpisode, bool) {
+	episode, ok := d.tracker.append(value)
+	if ok {
+		d.last = episode
+		d.recovered = true
+	}
+	return episode, ok
+}
+
+// Len returns the number of appended values.
+func (d *DrawdownStream) Len() int {
+	return d.tracker.count
+}
+
+// Equity returns the current equity.
+func (d *DrawdownStream) Equity() float64 {
+	return d.tracker.equity
+}
+
+// MaxDrawdown returns the largest drawdown so far.
+func (d *DrawdownStream) MaxDrawdown() float64 {
+	return d.tracker.maxDrawdown
+}
+
+// MaxDrawdownDuration returns the largest number of values spent below a peak so far.
+func (d *DrawdownStream) MaxDrawdownDuration() int {
+	return d.tracker.maxDuration
+}
+
+// CurrentDrawdown returns the current drawdown and the number of values spent below the peak, or 0 if the
+// equity is at its peak.
+func (d *DrawdownStream) CurrentDrawdown() (depth float64, duration int) {
+	return d.tracker.current()
+}
+
+// CurrentEpisode returns the drawdown in progress, if any.
+func (d *DrawdownStream) CurrentEpisode() (DrawdownEpisode, bool) {
+	if !d.tracker.inDrawdown {
+		return DrawdownEpisode{}, false
+	}
+	return d.tracker.episode(-1), true
+}
+
+// LastRecovered returns the last drawdown that has been recovered, if any.
+func (d *DrawdownStream) LastRecovered() (DrawdownEpisode, bool) {
+	return d.last, d.recovered
+}
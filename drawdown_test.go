@@ -0,0 +1,131 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math/rand"
+	"testing"
+)
+
+func TestDrawdown(t *testing.T) {
+
+	t.Run("Additive", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue([]float64{1, -2, -1, 3, 1, -1, 2}, true)
+		report := s.Drawdown(DrawdownAdditive)
+		g.Expect(report.MaxDrawdown).To(Equal(3.0))
+		g.Expect(report.MaxDrawdownDuration).To(Equal(2))
+		g.Expect(report.CurrentDrawdown).To(Equal(0.0))
+		g.Expect(report.CurrentDrawdownDuration).To(Equal(0))
+		g.Expect(report.Episodes).To(Equal([]DrawdownEpisode{
+			{Peak: 0, Trough: 2, Recovery: 3, Depth: 3, Duration: 2, TimeToRecovery: 1},
+			{Peak: 4, Trough: 5, Recovery: 6, Depth: 1, Duration: 1, TimeToRecovery: 1},
+		}))
+		g.Expect(report.MaxDrawdownDuration).To(Equal(maxEpisodeDuration(report.Episodes)))
+
+		s.Append(-1)
+		s.Append(-0.5)
+		report = s.Drawdown(DrawdownAdditive)
+		g.Expect(report.CurrentDrawdown).To(Equal(1.5))
+		g.Expect(report.CurrentDrawdownDuration).To(Equal(2))
+		g.Expect(report.Episodes).To(HaveLen(3))
+		g.Expect(report.Episodes[2]).To(Equal(DrawdownEpisode{
+			Peak: 6, Trough: 8, Recovery: -1, Depth: 1.5, Duration: 2, TimeToRecovery: -1,
+		}))
+		g.Expect(report.Episodes[2].Duration).To(Equal(report.CurrentDrawdownDuration))
+	})
+
+	t.Run("Initial drawdown", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue([]float64{-1, 1, 1}, true)
+		report := s.Drawdown(DrawdownAdditive)
+		g.Expect(report.Episodes).To(Equal([]DrawdownEpisode{
+			{Peak: -1, Trough: 0, Recovery: 1, Depth: 1, Duration: 1, TimeToRecovery: 1},
+		}))
+	})
+
+	t.Run("Compounded", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue([]float64{0.1, -0.5, 0.5, 0.5}, true)
+		report := s.Drawdown(DrawdownCompounded)
+		g.Expect(report.MaxDrawdown).To(BeNumerically("~", 0.5, 1e-12))
+		g.Expect(report.Episodes).To(HaveLen(1))
+		g.Expect(report.Episodes[0].Recovery).To(Equal(3))
+		g.Expect(report.Episodes[0].Depth).To(BeNumerically("~", 0.5, 1e-12))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue([]float64{1, -1}, false)
+		_, err := s.DrawdownE(DrawdownAdditive)
+		g.Expect(err).To(Equal(ErrNoOriginal))
+		g.Expect(func() { s.Drawdown(DrawdownAdditive) }).To(Panic())
+		s = NewSampleWithValue(nil, true)
+		g.Expect(s.Drawdown(DrawdownAdditive)).To(Equal(&DrawdownReport{}))
+		_, err = s.DrawdownE(DrawdownMode(0))
+		g.Expect(err).To(Equal(ErrUnknownDrawdownMode))
+		_, err = s.DrawdownE(DrawdownCompounded + 1)
+		g.Expect(err).To(Equal(ErrUnknownDrawdownMode))
+		g.Expect(func() { s.Drawdown(DrawdownMode(0)) }).To(PanicWith(ErrUnknownDrawdownMode))
+		g.Expect(func() { NewDrawdownStream(DrawdownMode(0)) }).To(Panic())
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r := rand.New(rand.NewSource(1))
+		values := make([]float64, 1000)
+		for i := range values {
+			values[i] = 0.01*r.NormFloat64() + 0.001
+		}
+		for _, mode := range []DrawdownMode{DrawdownAdditive, DrawdownCompounded} {
+			report := NewSampleWithValue(values, true).Drawdown(mode)
+			g.Expect(len(report.Episodes)).To(BeNumerically(">", 10))
+			g.Expect(report.MaxDrawdownDuration).To(Equal(maxEpisodeDuration(report.Episodes)))
+
+			d := NewDrawdownStream(mode)
+			var episodes []DrawdownEpisode
+			for _, value := range values {
+				if episode, ok := d.Append(value); ok {
+					episodes = append(episodes, episode)
+					last, _ := d.LastRecovered()
+					g.Expect(last).To(Equal(episode))
+				}
+			}
+			if current, ok := d.CurrentEpisode(); ok {
+				episodes = append(episodes, current)
+			}
+			g.Expect(episodes).To(Equal(report.Episodes))
+			g.Expect(d.Len()).To(Equal(len(values)))
+			g.Expect(d.MaxDrawdown()).To(Equal(report.MaxDrawdown))
+			g.Expect(d.MaxDrawdownDuration()).To(Equal(report.MaxDrawdownDuration))
+			depth, duration := d.CurrentDrawdown()
+			g.Expect(depth).To(Equal(report.CurrentDrawdown))
+			g.Expect(duration).To(Equal(report.CurrentDrawdownDuration))
+			d.CleanPool()
+		}
+	})
+}
+
+func maxEpisodeDuration(episodes []DrawdownEpisode) int {
+	max := 0
+	for _, episode := range episodes {
+		if episode.Duration > max {
+			max = episode.Duration
+		}
+	}
+	return max
+}
+
+func BenchmarkDrawdownStream(b *testing.B) {
+	points := make([]float64, 300)
+	for i := range points {
+		points[i] = float64(i%37 - 18)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d := NewDrawdownStream(DrawdownAdditive)
+		d.AppendMany(points)
+		d.MaxDrawdown()
+		d.CleanPool()
+	}
+}
@@ -0,0 +1,470 @@
+package gostats
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+)
+
+// The binary and JSON encodings of SampleStream and Sample start with a version, so that a release can keep
+// reading the state written by the previous ones. Unknown versions fail with ErrUnsupportedVersion. gob uses
+// the binary encoding.
+//
+// Version 2 of SampleStream adds the TradeStats section, version 1 is still read.
+const (
+	sampleStreamEncodingVersion = 2
+	sampleEncodingVersion       = 1
+)
+
+const (
+	encodingFlagInitialized = 1 << iota
+	encodingFlagDigest
+	encodingFlagWithOriginal
+	encodingFlagSorted
+	encodingFlagTradeStats
+)
+
+// binaryEncoder appends big endian values to data.
+type binaryEncoder struct {
+	data []byte
+}
+
+func (e *binaryEncoder) uint8(v uint8) {
+	e.data = append(e.data, v)
+}
+
+func (e *binaryEncoder) int16(v int16) {
+	e.data = append(e.data, byte(uint16(v)>>8), byte(v))
+}
+
+func (e *binaryEncoder) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.data = append(e.data, b[:]...)
+}
+
+func (e *binaryEncoder) float64(v float64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+	e.data = append(e.data, b[:]...)
+}
+
+func (e *binaryEncoder) float64s(vs []float64) {
+	e.uint32(uint32(len(vs)))
+	for _, v := range vs {
+		e.float64(v)
+	}
+}
+
+func (e *binaryEncoder) bytes(b []byte) {
+	e.uint32(uint32(len(b)))
+	e.data = append(e.data, b...)
+}
+
+// binaryDecoder reads the values written by binaryEncoder. Once data is too short, err is ErrInvalidEncoding
+// and the values read are zero.
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *binaryDecoder) next(n int) []byte {
+	if d.err != nil || len(d.data) < n {
+		d.err = ErrInvalidEncoding
+		return make([]byte, n)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *binaryDecoder) uint8() uint8 {
+	return d.next(1)[0]
+}
+
+func (d *binaryDecoder) int16() int16 {
+	return int16(binary.BigEndian.Uint16(d.next(2)))
+}
+
+func (d *binaryDecoder) uint32() uint32 {
+	return binary.BigEndian.Uint32(d.next(4))
+}
+
+func (d *binaryDecoder) float64() float64 {
+	return math.Float64frombits(binary.BigEndian.Uint64(d.next(8)))
+}
+
+func (d *binaryDecoder) float64s() []float64 {
+	n := int(d.uint32())
+	if d.err != nil || len(d.data) < 8*n {
+		d.err = ErrInvalidEncoding
+		return nil
+	}
+	vs := make([]float64, n)
+	for i := range vs {
+		vs[i] = d.float64()
+	}
+	return vs
+}
+
+func (d *binaryDecoder) bytes() []byte {
+	n := int(d.uint32())
+	if d.err != nil || len(d.data) < n {
+		d.err = ErrInvalidEncoding
+		return nil
+	}
+	return d.next(n)
+}
+
+// end returns the decoding error, if any, or ErrInvalidEncoding if some data has not been read.
+func (d *binaryDecoder) end() error {
+	if d.err == nil && len(d.data) != 0 {
+		return ErrInvalidEncoding
+	}
+	return d.err
+}
+
+// finiteOrNil returns nil for the values that JSON cannot represent.
+func finiteOrNil(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+func valueOr(v *float64, otherwise float64) float64 {
+	if v == nil {
+		return otherwise
+	}
+	return *v
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The states of the TDigest and the TradeStats are included,
+// if any.
+func (s *SampleStream) MarshalBinary() ([]byte, error) {
+	var flags uint8
+	if s._initialized {
+		flags |= encodingFlagInitialized
+	}
+	if s.digest != nil {
+		flags |= encodingFlagDigest
+	}
+	if s.trades != nil {
+		flags |= encodingFlagTradeStats
+	}
+	e := binaryEncoder{data: make([]byte, 0, 96)}
+	e.uint8(sampleStreamEncodingVersion)
+	e.uint8(flags)
+	for _, v := range []float64{s.sum, s.min, s.max, s._prevMean, s._prevVariance, s._m3, s._m4, s._count} {
+		e.float64(v)
+	}
+	for _, v := range []int16{s.nbPositives, s.nbProfitStreak, s.nbLossStreak, s._nbPositiveStreak,
+		s._nbNegativeStreak, s._leadingPositiveStreak, s._leadingNegativeStreak} {
+		e.int16(v)
+	}
+	if s.digest != nil {
+		digest, err := s.digest.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		e.bytes(digest)
+	}
+	if s.trades != nil {
+		trades, err := s.trades.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		e.bytes(trades)
+	}
+	return e.data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *SampleStream) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return ErrInvalidEncoding
+	}
+	version := data[0]
+	if version != 1 && version != sampleStreamEncodingVersion {
+		return ErrUnsupportedVersion
+	}
+	d := binaryDecoder{data: data[1:]}
+	flags := d.uint8()
+	if version == 1 && flags&encodingFlagTradeStats != 0 {
+		return ErrInvalidEncoding
+	}
+	var values [8]float64
+	for i := range values {
+		values[i] = d.float64()
+	}
+	var streaks [7]int16
+	for i := range streaks {
+		streaks[i] = d.int16()
+	}
+	var digest []byte
+	if flags&encodingFlagDigest != 0 {
+		digest = d.bytes()
+	}
+	var trades []byte
+	if flags&encodingFlagTradeStats != 0 {
+		trades = d.bytes()
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	if err := s.setSections(digest, trades); err != nil {
+		return err
+	}
+
+	s._initialized = flags&encodingFlagInitialized != 0
+	s.sum, s.min, s.max = values[0], values[1], values[2]
+	s._prevMean, s._prevVariance, s._m3, s._m4, s._count = values[3], values[4], values[5], values[6], values[7]
+	s.nbPositives, s.nbProfitStreak, s.nbLossStreak = streaks[0], streaks[1], streaks[2]
+	s._nbPositiveStreak, s._nbNegativeStreak = streaks[3], streaks[4]
+	s._leadingPositiveStreak, s._leadingNegativeStreak = streaks[5], streaks[6]
+	return nil
+}
+
+// setSections replaces the TDigest and the TradeStats of s by the encoded ones, or removes them if their encoding
+// is nil. Both are decoded before s is modified, so s is unchanged if one of them is invalid.
+func (s *SampleStream) setSections(encodedDigest, encodedTrades []byte) error {
+	var digest *TDigest
+	if encodedDigest != nil {
+		digest = NewTDigest(DefaultTDigestCompression)
+		if err := digest.UnmarshalBinary(encodedDigest); err != nil {
+			digest.CleanPool()
+			return err
+		}
+	}
+	var trades *TradeStats
+	if encodedTrades != nil {
+		trades = NewTradeStats(ZeroTradesAsLoss)
+		if err := trades.UnmarshalBinary(encodedTrades); err != nil {
+			trades.CleanPool()
+			if digest != nil {
+				digest.CleanPool()
+			}
+			return err
+		}
+	}
+
+	if s.digest != nil {
+		s.digest.CleanPool()
+	}
+	if s.trades != nil {
+		s.trades.CleanPool()
+	}
+	s.digest = digest
+	s.trades = trades
+	return nil
+}
+
+type sampleStreamJSON struct {
+	Version               int      `json:"version"`
+	Count                 float64  `json:"count"`
+	Sum                   float64  `json:"sum"`
+	Min                   *float64 `json:"min,omitempty"`
+	Max                   *float64 `json:"max,omitempty"`
+	Mean                  float64  `json:"mean"`
+	M2                    float64  `json:"m2"`
+	M3                    float64  `json:"m3"`
+	M4                    float64  `json:"m4"`
+	NbPositives           int16    `json:"nbPositives"`
+	NbProfitStreak        int16    `json:"nbProfitStreak"`
+	NbLossStreak          int16    `json:"nbLossStreak"`
+	PositiveStreak        int16    `json:"positiveStreak"`
+	NegativeStreak        int16    `json:"negativeStreak"`
+	LeadingPositiveStreak int16    `json:"leadingPositiveStreak"`
+	LeadingNegativeStreak int16    `json:"leadingNegativeStreak"`
+	Digest                []byte   `json:"digest,omitempty"`
+	TradeStats            []byte   `json:"tradeStats,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The TDigest and the TradeStats are included in their binary encoding,
+// if any.
+func (s *SampleStream) MarshalJSON() ([]byte, error) {
+	j := sampleStreamJSON{
+		Version:               sampleStreamEncodingVersion,
+		Count:                 s._count,
+		Sum:                   s.sum,
+		Mean:                  s._prevMean,
+		M2:                    s._prevVariance,
+		M3:                    s._m3,
+		M4:                    s._m4,
+		NbPositives:           s.nbPositives,
+		NbProfitStreak:        s.nbProfitStreak,
+		NbLossStreak:          s.nbLossStreak,
+		PositiveStreak:        s._nbPositiveStreak,
+		NegativeStreak:        s._nbNegativeStreak,
+		LeadingPositiveStreak: s._leadingPositiveStreak,
+		LeadingNegativeStreak: s._leadingNegativeStreak,
+	}
+	if s._initialized {
+		j.Min = finiteOrNil(s.min)
+		j.Max = finiteOrNil(s.max)
+	}
+	if s.digest != nil {
+		digest, err := s.digest.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		j.Digest = digest
+	}
+	if s.trades != nil {
+		trades, err := s.trades.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		j.TradeStats = trades
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *SampleStream) UnmarshalJSON(data []byte) error {
+	var j sampleStreamJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Version != 1 && j.Version != sampleStreamEncodingVersion {
+		return ErrUnsupportedVersion
+	}
+	if j.Version == 1 && j.TradeStats != nil {
+		return ErrInvalidEncoding
+	}
+	if err := s.setSections(j.Digest, j.TradeStats); err != nil {
+		return err
+	}
+	s._initialized = j.Count > 0
+	s._count = j.Count
+	s.sum = j.Sum
+	s.min = valueOr(j.Min, math.Inf(+1))
+	s.max = valueOr(j.Max, math.Inf(-1))
+	s._prevMean = j.Mean
+	s._prevVariance = j.M2
+	s._m3 = j.M3
+	s._m4 = j.M4
+	s.nbPositives = j.NbPositives
+	s.nbProfitStreak = j.NbProfitStreak
+	s.nbLossStreak = j.NbLossStreak
+	s._nbPositiveStreak = j.PositiveStreak
+	s._nbNegativeStreak = j.NegativeStreak
+	s._leadingPositiveStreak = j.LeadingPositiveStreak
+	s._leadingNegativeStreak = j.LeadingNegativeStreak
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The cached statistics are not encoded, they are computed
+// again when needed.
+func (s *Sample) MarshalBinary() ([]byte, error) {
+	var flags uint8
+	if s.withOriginal {
+		flags |= encodingFlagWithOriginal
+	}
+	if s.sorted {
+		flags |= encodingFlagSorted
+	}
+	e := binaryEncoder{data: make([]byte, 0, 32+8*(s.nb+len(s.original)))}
+	e.uint8(sampleEncodingVersion)
+	e.uint8(flags)
+	e.float64s(s.xs[:s.nb])
+	if s.withOriginal {
+		e.float64s(s.original)
+	}
+	for _, v := range []int16{s.nbProfitStreak, s.nbLossStreak, s._nbPositiveStreak, s._nbNegativeStreak} {
+		e.int16(v)
+	}
+	return e.data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *Sample) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return ErrInvalidEncoding
+	}
+	if data[0] != sampleEncodingVersion {
+		return ErrUnsupportedVersion
+	}
+	d := binaryDecoder{data: data[1:]}
+	flags := d.uint8()
+	xs := d.float64s()
+	var original []float64
+	if flags&encodingFlagWithOriginal != 0 {
+		original = d.float64s()
+	}
+	var streaks [4]int16
+	for i := range streaks {
+		streaks[i] = d.int16()
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	if original != nil && len(original) != len(xs) {
+		return ErrInvalidEncoding
+	}
+
+	s.restore(xs, original, flags&encodingFlagWithOriginal != 0, flags&encodingFlagSorted != 0, streaks)
+	return nil
+}
+
+// restore sets the state of s from decoded values. The streaks depend on the order of the values, which may
+// have been sorted, so they are decoded rather than computed again.
+func (s *Sample) restore(xs, original []float64, withOriginal, sorted bool, streaks [4]int16) {
+	s.initEmptyValues(withOriginal)
+	s.xs = make([]float64, 0, len(xs))
+	for _, value := range xs {
+		s.xs = append(s.xs, value)
+		s.walk(value)
+	}
+	if withOriginal {
+		s.original = append(make([]float64, 0, len(original)), original...)
+	}
+	s.sorted = sorted
+	s.nbProfitStreak, s.nbLossStreak = streaks[0], streaks[1]
+	s._nbPositiveStreak, s._nbNegativeStreak = streaks[2], streaks[3]
+}
+
+type sampleJSON struct {
+	Version        int       `json:"version"`
+	Values         []float64 `json:"values"`
+	Original       []float64 `json:"original,omitempty"`
+	WithOriginal   bool      `json:"withOriginal"`
+	Sorted         bool      `json:"sorted"`
+	NbProfitStreak int16     `json:"nbProfitStreak"`
+	NbLossStreak   int16     `json:"nbLossStreak"`
+	PositiveStreak int16     `json:"positiveStreak"`
+	NegativeStreak int16     `json:"negativeStreak"`
+}
+
+// MarshalJSON implements json.Marshaler. The values must be finite.
+func (s *Sample) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sampleJSON{
+		Version:        sampleEncodingVersion,
+		Values:         s.xs[:s.nb],
+		Original:       s.original,
+		WithOriginal:   s.withOriginal,
+		Sorted:         s.sorted,
+		NbProfitStreak: s.nbProfitStreak,
+		NbLossStreak:   s.nbLossStreak,
+		PositiveStreak: s._nbPositiveStreak,
+		NegativeStreak: s._nbNegativeStreak,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Sample) UnmarshalJSON(data []byte) error {
+	var j sampleJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Version != sampleEncodingVersion {
+		return ErrUnsupportedVersion
+	}
+	if j.WithOriginal && len(j.Original) != len(j.Values) {
+		return ErrInvalidEncoding
+	}
+
+	s.restore(j.Values, j.Original, j.WithOriginal, j.Sorted,
+		[4]int16{j.NbProfitStreak, j.NbLossStreak, j.PositiveStreak, j.NegativeStreak})
+	return nil
+}
@@ -0,0 +1,167 @@
+package gostats
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	. "github.com/onsi/gomega"
+	"math"
+	"testing"
+)
+
+func TestSampleStreamEncoding(t *testing.T) {
+
+	values := []float64{2, 8, -1, 0, 4, 1, 9, 9, -3, 0, 5}
+
+	codecs := map[string]struct {
+		encode func(s interface{}) ([]byte, error)
+		decode func(data []byte, s interface{}) error
+	}{
+		"Binary": {
+			encode: func(s interface{}) ([]byte, error) {
+				return s.(interface{ MarshalBinary() ([]byte, error) }).MarshalBinary()
+			},
+			decode: func(data []byte, s interface{}) error {
+				return s.(interface{ UnmarshalBinary([]byte) error }).UnmarshalBinary(data)
+			},
+		},
+		"JSON": {
+			encode: json.Marshal,
+			decode: json.Unmarshal,
+		},
+		"Gob": {
+			encode: func(s interface{}) ([]byte, error) {
+				var buf bytes.Buffer
+				err := gob.NewEncoder(&buf).Encode(s)
+				return buf.Bytes(), err
+			},
+			decode: func(data []byte, s interface{}) error {
+				return gob.NewDecoder(bytes.NewReader(data)).Decode(s)
+			},
+		},
+	}
+
+	for name, codec := range codecs {
+		codec := codec
+		t.Run(name, func(t *testing.T) {
+
+			t.Run("SampleStream", func(t *testing.T) {
+				g := NewGomegaWithT(t)
+				for _, withQuantiles := range []bool{false, true} {
+					s := NewSampleStream()
+					if withQuantiles {
+						s = NewSampleStreamWithQuantiles(DefaultTDigestCompression)
+					}
+					s.AppendMany(values[:6])
+					data, err := codec.encode(s)
+					g.Expect(err).NotTo(HaveOccurred())
+
+					decoded := NewSampleStream()
+					g.Expect(codec.decode(data, decoded)).To(Succeed())
+					expectSameStream(g, decoded, s)
+					g.Expect(decoded.Digest() != nil).To(Equal(withQuantiles))
+
+					// the decoded stream goes on like the original one
+					s.AppendMany(values[6:])
+					decoded.AppendMany(values[6:])
+					expectSameStream(g, decoded, s)
+					if withQuantiles {
+						g.Expect(decoded.Quantile(0.5)).To(Equal(s.Quantile(0.5)))
+					}
+					decoded.CleanPool()
+					s.CleanPool()
+				}
+			})
+
+			t.Run("Empty SampleStream", func(t *testing.T) {
+				g := NewGomegaWithT(t)
+				s := NewSampleStream()
+				data, err := codec.encode(s)
+				g.Expect(err).NotTo(HaveOccurred())
+
+				decoded := NewSampleStreamWithQuantiles(DefaultTDigestCompression)
+				decoded.Append(42)
+				g.Expect(codec.decode(data, decoded)).To(Succeed())
+				g.Expect(decoded.Len()).To(Equal(0))
+				g.Expect(decoded.Digest()).To(BeNil())
+				decoded.AppendMany(values)
+				s.AppendMany(values)
+				expectSameStream(g, decoded, s)
+				decoded.CleanPool()
+				s.CleanPool()
+			})
+
+			t.Run("Sample", func(t *testing.T) {
+				g := NewGomegaWithT(t)
+				for _, withOriginal := range []bool{false, true} {
+					s := NewSampleWithValue(values[:6], withOriginal)
+					s.Percentile(0.5) // sorts the values
+					data, err := codec.encode(s)
+					g.Expect(err).NotTo(HaveOccurred())
+
+					decoded := NewSampleFromPool(false)
+					g.Expect(codec.decode(data, decoded)).To(Succeed())
+					g.Expect(decoded.Xs()).To(Equal(s.Xs()))
+					if withOriginal {
+						g.Expect(decoded.Original()).To(Equal(values[:6]))
+					} else {
+						_, err := decoded.OriginalE()
+						g.Expect(err).To(Equal(ErrNoOriginal))
+					}
+
+					for _, value := range values[6:] {
+						s.Append(value)
+						decoded.Append(value)
+					}
+					g.Expect(decoded.Mean()).To(Equal(s.Mean()))
+					g.Expect(decoded.Variance()).To(Equal(s.Variance()))
+					g.Expect(decoded.Percentile(0.25)).To(Equal(s.Percentile(0.25)))
+					g.Expect(decoded.NbPositive()).To(Equal(s.NbPositive()))
+					g.Expect(decoded.NbPositiveStreak()).To(Equal(s.NbPositiveStreak()))
+					g.Expect(decoded.NbNegativeStreak()).To(Equal(s.NbNegativeStreak()))
+					g.Expect(decoded.nbProfitStreak).To(Equal(s.nbProfitStreak))
+					g.Expect(decoded.nbLossStreak).To(Equal(s.nbLossStreak))
+					decoded.BackToPool()
+				}
+			})
+		})
+	}
+
+	t.Run("Invalid encodings", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleStreamWithQuantiles(DefaultTDigestCompression)
+		s.AppendMany(values)
+		data, _ := s.MarshalBinary()
+		g.Expect(s.UnmarshalBinary(data[:len(data)-1])).To(Equal(ErrInvalidEncoding))
+		g.Expect(s.UnmarshalBinary(append(data, 0))).To(Equal(ErrInvalidEncoding))
+		g.Expect(s.UnmarshalBinary(nil)).To(Equal(ErrInvalidEncoding))
+		data[0] = 3
+		g.Expect(s.UnmarshalBinary(data)).To(Equal(ErrUnsupportedVersion))
+		g.Expect(json.Unmarshal([]byte(`{"version":3}`), s)).To(Equal(ErrUnsupportedVersion))
+		g.Expect(s.Len()).To(Equal(len(values)))
+		s.CleanPool()
+
+		sample := NewSampleWithValue(values, true)
+		data, _ = sample.MarshalBinary()
+		g.Expect(sample.UnmarshalBinary(data[:len(data)-1])).To(Equal(ErrInvalidEncoding))
+		data[0] = 2
+		g.Expect(sample.UnmarshalBinary(data)).To(Equal(ErrUnsupportedVersion))
+		g.Expect(json.Unmarshal([]byte(`{"version":1,"values":[1,2],"original":[1],"withOriginal":true}`), sample)).
+			To(Equal(ErrInvalidEncoding))
+		g.Expect(sample.Len()).To(Equal(len(values)))
+	})
+
+	t.Run("JSON has no infinite values", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleStream()
+		data, err := json.Marshal(s)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(data)).NotTo(ContainSubstring("min"))
+		decoded := NewSampleStream()
+		g.Expect(json.Unmarshal(data, decoded)).To(Succeed())
+		min, max := decoded.Bounds()
+		g.Expect(math.IsInf(min, +1) && math.IsInf(max, -1)).To(BeTrue())
+		decoded.CleanPool()
+		s.CleanPool()
+	})
+}
@@ -0,0 +1,43 @@
+package gostats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/a-lucas/go-stats/stats"
+)
+
+var (
+	ErrSampleSize           = stats.ErrSampleSize
+	ErrMismatchedSamples    = stats.ErrMismatchedSamples
+	ErrNoOriginal           = errors.New("sample has no original values")
+	ErrUnknownDistance      = errors.New("unknown distance type")
+	ErrMinkowskiOrder       = errors.New("minkowski order must be >= 1")
+	ErrInvalidEncoding      = errors.New("invalid encoding")
+	ErrUnsupportedVersion   = errors.New("unsupported encoding version")
+	ErrUnknownCorrelation   = errors.New("unknown correlation type")
+	ErrIncompatibleSketches = errors.New("sketches have different parameters")
+	ErrValueOutOfRange      = errors.New("value out of the histogram range")
+	ErrNegativeCount        = errors.New("subtraction gives negative counts")
+	ErrNegativeRecordCount  = errors.New("record count must be >= 0")
+	ErrTooManyKeys          = errors.New("too many keys")
+	ErrInvalidConfidence    = errors.New("confidence must be in (0, 1)")
+	ErrUnknownRiskMethod    = errors.New("unknown risk method")
+	ErrInvalidTrials        = errors.New("trials must be >= 2 with a non-negative Sharpe ratio variance")
+	ErrZeroVector           = errors.New("distance undefined for zero vectors")
+	ErrMismatchedTradeStats = errors.New("trade stats handle zero trades differently")
+	ErrUnknownDrawdownMode  = errors.New("unknown drawdown mode")
+)
+
+// nanOrPanic keeps the behaviour of the panicking API on top of its
+// error-returning counterpart: size problems and undefined distances give
+// NaN, anything else is a misuse and panics.
+func nanOrPanic(value float64, err error) float64 {
+	switch err {
+	case nil:
+		return value
+	case ErrSampleSize, ErrMismatchedSamples, ErrZeroVector:
+		return math.NaN()
+	}
+	panic(err)
+}
@@ -0,0 +1,76 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+
+	t.Run("OriginalE", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := NewSampleWithValue([]float64{1, 2}, false).OriginalE()
+		g.Expect(err).To(Equal(ErrNoOriginal))
+		original, err := NewSampleWithValue([]float64{1, 2}, true).OriginalE()
+		g.Expect(err).To(BeNil())
+		g.Expect(original).To(Equal([]float64{1, 2}))
+		g.Expect(func() { NewSampleWithValue([]float64{1, 2}, false).Original() }).To(Panic())
+	})
+
+	t.Run("Pair validation", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s1 := NewSampleWithValue([]float64{1, 2, 3}, true)
+		s2 := NewSampleWithValue([]float64{1, 2}, true)
+		s3 := NewSampleWithValue([]float64{}, true)
+		s4 := NewSampleWithValue([]float64{1, 2, 3}, false)
+
+		_, err := s1.CovariancePopulationE(s2)
+		g.Expect(err).To(Equal(ErrMismatchedSamples))
+		_, err = s1.CovariancePopulationE(s3)
+		g.Expect(err).To(Equal(ErrSampleSize))
+		_, err = s1.CovariancePopulationE(s4)
+		g.Expect(err).To(Equal(ErrNoOriginal))
+		g.Expect(func() { s1.CovariancePopulation(s4) }).To(Panic())
+
+		_, err = s1.DistanceE(s2, DistanceManhattan)
+		g.Expect(err).To(Equal(ErrMismatchedSamples))
+		g.Expect(math.IsNaN(s1.Distance(s2, DistanceManhattan))).To(BeTrue())
+		_, err = s1.DistanceE(s1, DistanceType(-1))
+		g.Expect(err).To(Equal(ErrUnknownDistance))
+		g.Expect(func() { s1.Distance(s1, DistanceType(-1)) }).To(Panic())
+
+		for _, ct := range []CorrelationType{CorrelationPearson, CorrelationSpearman, CorrelationKendall} {
+			_, err = s1.CorrelationE(s2, ct)
+			g.Expect(err).To(Equal(ErrMismatchedSamples))
+			_, err = s1.CorrelationTestE(s4, ct)
+			g.Expect(err).To(Equal(ErrNoOriginal))
+		}
+		_, err = s1.CorrelationE(s1, "ErrorsUnknown")
+		g.Expect(err).To(Equal(ErrUnknownCorrelation))
+
+		// Distance and Pearson check the lengths first, and stay NaN for
+		// Samples without original values.
+		s5 := NewSampleWithValue([]float64{1, 2}, false)
+		s6 := NewSampleWithValue([]float64{}, false)
+		for _, pair := range [][2]*Sample{{s4, s5}, {s4, s6}, {s6, s6}, {s1, s5}} {
+			_, err = pair[0].DistanceE(pair[1], DistanceManhattan)
+			g.Expect(err).To(BeElementOf(ErrSampleSize, ErrMismatchedSamples))
+			g.Expect(math.IsNaN(pair[0].Distance(pair[1], DistanceEuclidean))).To(BeTrue())
+			g.Expect(math.IsNaN(pair[0].Correlation(pair[1], CorrelationPearson))).To(BeTrue())
+			g.Expect(math.IsNaN(pair[0].CorrelationTest(pair[1], CorrelationPearson).Coefficient)).To(BeTrue())
+		}
+		_, err = s4.DistanceE(s4, DistanceManhattan)
+		g.Expect(err).To(Equal(ErrNoOriginal))
+	})
+
+	t.Run("Chebyshev", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s1 := NewSampleWithValue([]float64{1, 2, 3}, true)
+		s2 := NewSampleWithValue([]float64{2, 4, 1}, true)
+		d, err := s1.DistanceE(s2, DistanceChebyshev)
+		g.Expect(err).To(BeNil())
+		g.Expect(d).To(Equal(2.0))
+	})
+
+}
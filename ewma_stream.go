@@ -0,0 +1,129 @@
+package gostats
+
+import (
+	"math"
+	"sync"
+)
+
+var EWMAStreamPool = sync.Pool{
+	New: func() interface{} { return new(EWMAStream) },
+}
+
+// EWMAStream is the exponentially weighted counterpart of SampleStream: the i'th most recent value has a
+// weight of (1 - alpha)^i, so old values fade out instead of counting as much as new ones.
+//
+// Weights are not normalized to start at alpha (like pandas' `adjust=True`), so the first values are not
+// biased toward zero, and Variance is corrected for the bias of the weighted estimator. Every Append is O(1)
+// and does not allocate.
+type EWMAStream struct {
+	alpha float64
+	// internal values
+	count float64
+	// streaming
+	_mean    float64
+	_m2      float64
+	_squares float64
+	_weight  float64
+	_weight2 float64
+}
+
+// NewEWMAStream returns an EWMAStream with the given smoothing factor. It panics unless 0 < alpha <= 1.
+func NewEWMAStream(alpha float64) *EWMAStream {
+	if !(alpha > 0 && alpha <= 1) {
+		panic("EWMAStream alpha must be in (0, 1]")
+	}
+	s := EWMAStreamPool.Get().(*EWMAStream)
+	s.initEmptyValues(alpha)
+	return s
+}
+
+// NewEWMAStreamHalfLife returns an EWMAStream whose weights halve every halfLife values. It panics unless
+// halfLife > 0.
+func NewEWMAStreamHalfLife(halfLife float64) *EWMAStream {
+	if !(halfLife > 0) {
+		panic("EWMAStream half-life must be positive")
+	}
+	return NewEWMAStream(1 - math.Exp(-math.Ln2/halfLife))
+}
+
+// NewEWMAStreamSpan returns an EWMAStream whose center of mass is at (span - 1) / 2 values, ie alpha is
+// 2 / (span + 1). It panics unless span >= 1.
+func NewEWMAStreamSpan(span float64) *EWMAStream {
+	if !(span >= 1) {
+		panic("EWMAStream span must be >= 1")
+	}
+	return NewEWMAStream(2 / (span + 1))
+}
+
+func (s *EWMAStream) CleanPool() {
+	EWMAStreamPool.Put(s)
+}
+
+func (s *EWMAStream) initEmptyValues(alpha float64) {
+	s.alpha = alpha
+	s.count = 0
+	s._mean = 0
+	s._m2 = 0
+	s._squares = 0
+	s._weight = 0
+	s._weight2 = 0
+}
+
+func (s *EWMAStream) AppendMany(values []float64) {
+	for _, value := range values {
+		s.Append(value)
+	}
+}
+
+func (s *EWMAStream) Append(value float64) {
+	decay := 1 - s.alpha
+	s.count = s.count + 1
+	// Decaying the weights of the previous values does not move the mean, and scales the squared deviations.
+	s._weight = s._weight*decay + 1
+	s._weight2 = s._weight2*decay*decay + 1
+	s._m2 = s._m2 * decay
+	s._squares = s._squares*decay + value*value
+
+	delta := value - s._mean
+	s._mean = s._mean + delta/s._weight
+	s._m2 = s._m2 + delta*(value-s._mean)
+}
+
+// Alpha returns the smoothing factor.
+func (s *EWMAStream) Alpha() float64 {
+	return s.alpha
+}
+
+// Len returns the number of appended values.
+func (s *EWMAStream) Len() int {
+	return int(s.count)
+}
+
+// Mean returns the exponentially weighted mean.
+func (s *EWMAStream) Mean() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	return s._mean
+}
+
+// Variance returns the bias-corrected exponentially weighted variance. It is NaN for less than 2 values.
+func (s *EWMAStream) Variance() float64 {
+	return s._m2 / (s._weight - s._weight2/s._weight)
+}
+
+// VarianceBiased returns the exponentially weighted variance, without bias correction.
+func (s *EWMAStream) VarianceBiased() float64 {
+	return s._m2 / s._weight
+}
+
+// VarianceZeroMean returns the exponentially weighted mean of the squared values, ie the variance assuming a
+// zero mean, as RiskMetrics does for returns.
+func (s *EWMAStream) VarianceZeroMean() float64 {
+	return s._squares / s._weight
+}
+
+// StdDev returns the bias-corrected exponentially weighted standard deviation.
+func (s *EWMAStream) StdDev() float64 {
+	return math.Pow(s.Variance(), 0.5)
+}
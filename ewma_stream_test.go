@@ -0,0 +1,87 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEWMAStream(t *testing.T) {
+
+	t.Run("Small", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewEWMAStream(0.5)
+		g.Expect(math.IsNaN(s.Mean())).To(BeTrue())
+		s.Append(1)
+		g.Expect(s.Mean()).To(Equal(1.0))
+		g.Expect(math.IsNaN(s.Variance())).To(BeTrue())
+		s.Append(2)
+		s.Append(3)
+		// weights are 0.25, 0.5 and 1
+		g.Expect(s.Mean()).To(BeNumerically("~", 4.25/1.75, 1e-12))
+		g.Expect(s.Variance()).To(BeNumerically("~", 0.9285714285714286, 1e-12))
+		g.Expect(s.VarianceBiased()).To(BeNumerically("~", 0.9285714285714286/1.75, 1e-12))
+		g.Expect(s.VarianceZeroMean()).To(BeNumerically("~", (9+2+0.25)/1.75, 1e-12))
+		g.Expect(s.Len()).To(Equal(3))
+		s.CleanPool()
+	})
+
+	t.Run("Against weighted Sample", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r := rand.New(rand.NewSource(1))
+		s := NewEWMAStreamSpan(20)
+		values := make([]float64, 200)
+		for i := range values {
+			values[i] = r.NormFloat64()*3 + 50
+		}
+		s.AppendMany(values)
+
+		var w1, w2, mean, m2 float64
+		for i, v := range values {
+			w := math.Pow(1-s.Alpha(), float64(len(values)-1-i))
+			w1 += w
+			w2 += w * w
+			mean += w * v
+		}
+		mean /= w1
+		for i, v := range values {
+			w := math.Pow(1-s.Alpha(), float64(len(values)-1-i))
+			m2 += w * (v - mean) * (v - mean)
+		}
+		g.Expect(s.Mean()).To(BeNumerically("~", mean, 1e-9))
+		g.Expect(s.Variance()).To(BeNumerically("~", m2/(w1-w2/w1), 1e-9))
+		g.Expect(s.StdDev()).To(BeNumerically("~", math.Sqrt(m2/(w1-w2/w1)), 1e-9))
+		s.CleanPool()
+	})
+
+	t.Run("Parameterization", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewEWMAStreamSpan(9)
+		g.Expect(s.Alpha()).To(Equal(0.2))
+		s.CleanPool()
+		s = NewEWMAStreamHalfLife(10)
+		g.Expect(math.Pow(1-s.Alpha(), 10)).To(BeNumerically("~", 0.5, 1e-12))
+		s.CleanPool()
+		g.Expect(func() { NewEWMAStream(0) }).To(Panic())
+		g.Expect(func() { NewEWMAStreamHalfLife(-1) }).To(Panic())
+		g.Expect(func() { NewEWMAStreamSpan(0.5) }).To(Panic())
+	})
+
+}
+
+func BenchmarkEWMAStream(b *testing.B) {
+	points := make([]float64, 300)
+	for i := range points {
+		points[i] = float64(i)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := NewEWMAStream(0.06)
+		s.AppendMany(points)
+		s.Mean()
+		s.StdDev()
+		s.CleanPool()
+	}
+}
@@ -0,0 +1,517 @@
+package gostats
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+const hdrHistogramEncodingVersion = 1
+
+var HdrHistogramPool = sync.Pool{
+	New: func() interface{} { return new(HdrHistogram) },
+}
+
+// HdrHistogram is a High Dynamic Range histogram (Tene's HdrHistogram) of integer values: values in
+// [lowest, highest] are recorded in O(1) into log-linear buckets, keeping significantFigures decimal digits of
+// precision. With 3 significant figures, the recorded values are all known within 0.1%, whatever their range.
+//
+// The buckets are grouped by powers of two, each group being split into 2 × 10^significantFigures (rounded to
+// a power of two) linear sub-buckets. The memory used only depends on the range and the precision.
+// Histograms can be merged and subtracted, so that interval histograms can be computed from snapshots.
+type HdrHistogram struct {
+	lowest             int64
+	highest            int64
+	significantFigures int
+	// bucket geometry
+	unitMagnitude               uint
+	subBucketHalfCountMagnitude uint
+	subBucketCount              int
+	subBucketHalfCount          int
+	subBucketMask               int64
+	counts                      []int64
+	// internal values
+	totalCount int64
+	min        int64
+	max        int64
+}
+
+// HdrBucket is a non empty bucket of a HdrHistogram, holding Count values in [From, To].
+type HdrBucket struct {
+	From  int64
+	To    int64
+	Count int64
+}
+
+// NewHdrHistogram returns an empty HdrHistogram for the values in [lowest, highest], with significantFigures
+// decimal digits of precision. It panics if lowest < 1, highest < 2 × lowest or significantFigures is not in
+// [1, 5].
+func NewHdrHistogram(lowest, highest int64, significantFigures int) *HdrHistogram {
+	if problem := checkHdrHistogram(lowest, highest, significantFigures); problem != "" {
+		panic(problem)
+	}
+	h := HdrHistogramPool.Get().(*HdrHistogram)
+	h.initEmptyValues(lowest, highest, significantFigures)
+	return h
+}
+
+func (h *HdrHistogram) CleanPool() {
+	HdrHistogramPool.Put(h)
+}
+
+// checkHdrHistogram returns why an HdrHistogram cannot have these parameters, or "" if it can.
+func checkHdrHistogram(lowest, highest int64, significantFigures int) string {
+	switch {
+	case lowest < 1:
+		return "HdrHistogram lowest value must be >= 1"
+	case highest/2 < lowest:
+		return "HdrHistogram highest value must be >= 2 * lowest"
+	case significantFigures < 1 || significantFigures > 5:
+		return "HdrHistogram significant figures must be in [1, 5]"
+	case bits.Len64(uint64(lowest))-1+int(subBucketCountMagnitude(significantFigures)) > 62:
+		// The first bucket would not fit in an int64.
+		return "HdrHistogram lowest value is too large for the significant figures"
+	}
+	return ""
+}
+
+// subBucketCountMagnitude returns the log2 of the number of sub-buckets, which must resolve single units up to
+// 2 × 10^significantFigures.
+func subBucketCountMagnitude(significantFigures int) uint {
+	largestWithSingleUnitResolution := 2 * int64(math.Pow10(significantFigures))
+	return uint(bits.Len64(uint64(largestWithSingleUnitResolution - 1)))
+}
+
+func (h *HdrHistogram) initEmptyValues(lowest, highest int64, significantFigures int) {
+	n := h.setGeometry(lowest, highest, significantFigures)
+	if cap(h.counts) < n {
+		h.counts = make([]int64, n)
+	} else {
+		h.counts = h.counts[:n]
+		for i := range h.counts {
+			h.counts[i] = 0
+		}
+	}
+
+	h.totalCount = 0
+	h.min = math.MaxInt64
+	h.max = 0
+}
+
+// setGeometry sets the parameters and the bucket geometry of h, and returns the number of counts it needs.
+func (h *HdrHistogram) setGeometry(lowest, highest int64, significantFigures int) int {
+	h.lowest = lowest
+	h.highest = highest
+	h.significantFigures = significantFigures
+
+	subBucketCountMagnitude := subBucketCountMagnitude(significantFigures)
+	h.subBucketHalfCountMagnitude = subBucketCountMagnitude - 1
+	h.unitMagnitude = uint(bits.Len64(uint64(lowest)) - 1)
+	h.subBucketCount = 1 << subBucketCountMagnitude
+	h.subBucketHalfCount = h.subBucketCount / 2
+	h.subBucketMask = int64(h.subBucketCount-1) << h.unitMagnitude
+
+	bucketCount := 1
+	smallestUntrackable := int64(h.subBucketCount) << h.unitMagnitude
+	for smallestUntrackable <= highest {
+		if smallestUntrackable > math.MaxInt64/2 {
+			bucketCount++
+			break
+		}
+		smallestUntrackable <<= 1
+		bucketCount++
+	}
+	return (bucketCount + 1) * h.subBucketHalfCount
+}
+
+// Clone returns a copy of h, taken from the pool.
+func (h *HdrHistogram) Clone() *HdrHistogram {
+	c := NewHdrHistogram(h.lowest, h.highest, h.significantFigures)
+	copy(c.counts, h.counts)
+	c.totalCount = h.totalCount
+	c.min = h.min
+	c.max = h.max
+	return c
+}
+
+// RecordValue records a value. It fails with ErrValueOutOfRange if the value is negative or above the range
+// of the histogram.
+func (h *HdrHistogram) RecordValue(value int64) error {
+	return h.RecordValues(value, 1)
+}
+
+// RecordValues records n times a value, see RecordValue. It fails with ErrNegativeRecordCount if n < 0.
+func (h *HdrHistogram) RecordValues(value, n int64) error {
+	if n < 0 {
+		return ErrNegativeRecordCount
+	}
+	if value < 0 {
+		return ErrValueOutOfRange
+	}
+	index := h.countsIndexOf(value)
+	if index >= len(h.counts) {
+		return ErrValueOutOfRange
+	}
+	if n == 0 {
+		return nil
+	}
+	h.counts[index] += n
+	h.totalCount = h.totalCount + n
+	if value < h.min {
+		h.min = value
+	}
+	if value > h.max {
+		h.max = value
+	}
+	return nil
+}
+
+func (h *HdrHistogram) bucketIndex(value int64) int {
+	// Smallest power of 2 containing value, at least the size of the first bucket.
+	pow2Ceiling := uint(64 - bits.LeadingZeros64(uint64(value|h.subBucketMask)))
+	return int(pow2Ceiling - h.unitMagnitude - (h.subBucketHalfCountMagnitude + 1))
+}
+
+func (h *HdrHistogram) subBucketIndex(value int64, bucketIndex int) int {
+	return int(value >> (uint(bucketIndex) + h.unitMagnitude))
+}
+
+func (h *HdrHistogram) countsIndex(bucketIndex, subBucketIndex int) int {
+	// The first half of the sub-buckets of a bucket overlaps with the previous bucket, except for bucket 0.
+	return (bucketIndex+1)<<h.subBucketHalfCountMagnitude + subBucketIndex - h.subBucketHalfCount
+}
+
+func (h *HdrHistogram) countsIndexOf(value int64) int {
+	bucketIndex := h.bucketIndex(value)
+	return h.countsIndex(bucketIndex, h.subBucketIndex(value, bucketIndex))
+}
+
+// valueFromIndex returns the lowest value of the counts index.
+func (h *HdrHistogram) valueFromIndex(index int) int64 {
+	bucketIndex := (index >> h.subBucketHalfCountMagnitude) - 1
+	subBucketIndex := (index & (h.subBucketHalfCount - 1)) + h.subBucketHalfCount
+	if bucketIndex < 0 {
+		subBucketIndex -= h.subBucketHalfCount
+		bucketIndex = 0
+	}
+	return int64(subBucketIndex) << (uint(bucketIndex) + h.unitMagnitude)
+}
+
+// equivalentRange returns the size of the range of values counted with value.
+func (h *HdrHistogram) equivalentRange(value int64) int64 {
+	bucketIndex := h.bucketIndex(value)
+	if h.subBucketIndex(value, bucketIndex) >= h.subBucketCount {
+		bucketIndex++
+	}
+	return 1 << (h.unitMagnitude + uint(bucketIndex))
+}
+
+// LowestEquivalentValue returns the lowest value counted with value.
+func (h *HdrHistogram) LowestEquivalentValue(value int64) int64 {
+	bucketIndex := h.bucketIndex(value)
+	subBucketIndex := h.subBucketIndex(value, bucketIndex)
+	return int64(subBucketIndex) << (uint(bucketIndex) + h.unitMagnitude)
+}
+
+// HighestEquivalentValue returns the highest value counted with value.
+func (h *HdrHistogram) HighestEquivalentValue(value int64) int64 {
+	return h.LowestEquivalentValue(value) + h.equivalentRange(value) - 1
+}
+
+func (h *HdrHistogram) medianEquivalentValue(value int64) int64 {
+	return h.LowestEquivalentValue(value) + h.equivalentRange(value)>>1
+}
+
+// SignificantFigures returns the number of significant decimal digits of the histogram.
+func (h *HdrHistogram) SignificantFigures() int {
+	return h.significantFigures
+}
+
+// TotalCount returns the number of recorded values.
+func (h *HdrHistogram) TotalCount() int64 {
+	return h.totalCount
+}
+
+// Bounds returns the minimum and maximum recorded values, or 0 if the histogram is empty. They are exact,
+// unless the histogram is the result of a Subtract.
+func (h *HdrHistogram) Bounds() (min int64, max int64) {
+	if h.totalCount == 0 {
+		return 0, 0
+	}
+	return h.min, h.max
+}
+
+// Mean returns the mean of the recorded values, each one being counted as the middle of its bucket. It is NaN
+// if the histogram is empty.
+func (h *HdrHistogram) Mean() float64 {
+	if h.totalCount == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for i, c := range h.counts {
+		if c != 0 {
+			sum = sum + float64(c)*float64(h.medianEquivalentValue(h.valueFromIndex(i)))
+		}
+	}
+	return sum / float64(h.totalCount)
+}
+
+// StdDev returns the population standard deviation of the recorded values, each one being counted as the
+// middle of its bucket. It is NaN if the histogram is empty.
+func (h *HdrHistogram) StdDev() float64 {
+	mean := h.Mean()
+	if math.IsNaN(mean) {
+		return mean
+	}
+	sum := 0.0
+	for i, c := range h.counts {
+		if c != 0 {
+			d := float64(h.medianEquivalentValue(h.valueFromIndex(i))) - mean
+			sum = sum + float64(c)*d*d
+		}
+	}
+	return math.Sqrt(sum / float64(h.totalCount))
+}
+
+// ValueAtQuantile returns the highest value equivalent to the value at the quantile q of the recorded values,
+// and the lowest one for q = 0. It is 0 if the histogram is empty.
+func (h *HdrHistogram) ValueAtQuantile(q float64) int64 {
+	if h.totalCount == 0 {
+		return 0
+	}
+	if q > 1 {
+		q = 1
+	}
+	countAtQuantile := int64(q*float64(h.totalCount) + 0.5)
+	if countAtQuantile < 1 {
+		countAtQuantile = 1
+	}
+	total := int64(0)
+	for i, c := range h.counts {
+		total = total + c
+		if total >= countAtQuantile {
+			value := h.valueFromIndex(i)
+			if q <= 0 {
+				return h.LowestEquivalentValue(value)
+			}
+			return h.HighestEquivalentValue(value)
+		}
+	}
+	return 0
+}
+
+// Buckets appends the non empty buckets of the histogram, in increasing order, to dst and returns it.
+func (h *HdrHistogram) Buckets(dst []HdrBucket) []HdrBucket {
+	for i, c := range h.counts {
+		if c != 0 {
+			value := h.valueFromIndex(i)
+			dst = append(dst, HdrBucket{
+				From:  value,
+				To:    h.HighestEquivalentValue(value),
+				Count: c,
+			})
+		}
+	}
+	return dst
+}
+
+// sameGeometry returns whether the counts of other can be used as they are in h.
+func (h *HdrHistogram) sameGeometry(other *HdrHistogram) bool {
+	return h.unitMagnitude == other.unitMagnitude && h.subBucketCount == other.subBucketCount &&
+		len(h.counts) >= len(other.counts)
+}
+
+// Merge adds the values of other to h. Unless the histograms have the same geometry, the values of other are
+// recorded as the middle of their bucket. It fails with ErrValueOutOfRange, without modifying h, if some values
+// of other are out of the range of h. other is not modified.
+func (h *HdrHistogram) Merge(other *HdrHistogram) error {
+	if other.totalCount == 0 {
+		return nil
+	}
+	if h.sameGeometry(other) {
+		for i, c := range other.counts {
+			h.counts[i] += c
+		}
+	} else {
+		if h.countsIndexOf(other.medianEquivalentValue(other.max)) >= len(h.counts) {
+			return ErrValueOutOfRange
+		}
+		for i, c := range other.counts {
+			if c != 0 {
+				h.counts[h.countsIndexOf(other.medianEquivalentValue(other.valueFromIndex(i)))] += c
+			}
+		}
+	}
+	h.totalCount = h.totalCount + other.totalCount
+	if other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	return nil
+}
+
+// Subtract removes the values of other from h, typically to get the values recorded since the snapshot other
+// has been cloned. It fails with ErrNegativeCount, without modifying h, if other has values that h does not
+// have. other is not modified.
+func (h *HdrHistogram) Subtract(other *HdrHistogram) error {
+	if other.totalCount == 0 {
+		return nil
+	}
+	index := func(i int) int { return i }
+	if !h.sameGeometry(other) {
+		index = func(i int) int { return h.countsIndexOf(other.medianEquivalentValue(other.valueFromIndex(i))) }
+	}
+	// Subtract in a copy, so that h is not modified on error.
+	counts := make([]int64, len(h.counts))
+	copy(counts, h.counts)
+	for i, c := range other.counts {
+		if c == 0 {
+			continue
+		}
+		j := index(i)
+		if j >= len(counts) || counts[j] < c {
+			return ErrNegativeCount
+		}
+		counts[j] -= c
+	}
+	copy(h.counts, counts)
+	h.totalCount = h.totalCount - other.totalCount
+	h.resetBounds()
+	return nil
+}
+
+// resetBounds sets min & max to the bounds of the non empty buckets.
+func (h *HdrHistogram) resetBounds() {
+	h.min = math.MaxInt64
+	h.max = 0
+	for i, c := range h.counts {
+		if c != 0 {
+			value := h.valueFromIndex(i)
+			if value < h.min {
+				h.min = value
+			}
+			h.max = h.HighestEquivalentValue(value)
+		}
+	}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The counts are run-length and varint encoded, then
+// compressed with zlib.
+func (h *HdrHistogram) MarshalBinary() ([]byte, error) {
+	var payload bytes.Buffer
+	var b [binary.MaxVarintLen64]byte
+	putVarint := func(v int64) {
+		payload.Write(b[:binary.PutVarint(b[:], v)])
+	}
+	putVarint(h.lowest)
+	putVarint(h.highest)
+	putVarint(int64(h.significantFigures))
+	putVarint(h.min)
+	putVarint(h.max)
+
+	// Runs of zero counts are written as their negated length, trailing zeros are not written.
+	last := len(h.counts) - 1
+	for last >= 0 && h.counts[last] == 0 {
+		last--
+	}
+	putVarint(int64(last + 1))
+	zeros := int64(0)
+	for _, c := range h.counts[:last+1] {
+		if c == 0 {
+			zeros++
+			continue
+		}
+		if zeros > 0 {
+			putVarint(-zeros)
+			zeros = 0
+		}
+		putVarint(c)
+	}
+
+	var data bytes.Buffer
+	data.WriteByte(hdrHistogramEncodingVersion)
+	w := zlib.NewWriter(&data)
+	if _, err := w.Write(payload.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The decoded parameters are checked like those of
+// NewHdrHistogram before the counts are allocated, so their memory is the one of a new histogram of the same
+// range and precision.
+func (h *HdrHistogram) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return ErrInvalidEncoding
+	}
+	if data[0] != hdrHistogramEncodingVersion {
+		return ErrUnsupportedVersion
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data[1:]))
+	if err != nil {
+		return ErrInvalidEncoding
+	}
+	defer zr.Close()
+	r := bufio.NewReader(zr)
+
+	var header [6]int64
+	for i := range header {
+		if header[i], err = binary.ReadVarint(r); err != nil {
+			return ErrInvalidEncoding
+		}
+	}
+	lowest, highest, significantFigures, min, max, n := header[0], header[1], header[2], header[3], header[4], header[5]
+	if significantFigures < 1 || significantFigures > 5 ||
+		checkHdrHistogram(lowest, highest, int(significantFigures)) != "" {
+		return ErrInvalidEncoding
+	}
+	// The whole header is checked before the counts are allocated, the bounds being those of an empty
+	// histogram when there is no count.
+	var geometry HdrHistogram
+	if n < 0 || n > int64(geometry.setGeometry(lowest, highest, int(significantFigures))) {
+		return ErrInvalidEncoding
+	}
+	if n == 0 && (min != math.MaxInt64 || max != 0) || n > 0 && (min < 0 || min > max) {
+		return ErrInvalidEncoding
+	}
+
+	h.initEmptyValues(lowest, highest, int(significantFigures))
+	for i := 0; i < int(n); {
+		c, err := binary.ReadVarint(r)
+		if err != nil {
+			return ErrInvalidEncoding
+		}
+		if c < 0 {
+			if -c > n-int64(i) {
+				return ErrInvalidEncoding
+			}
+			i += int(-c)
+			continue
+		}
+		if c > math.MaxInt64-h.totalCount {
+			return ErrInvalidEncoding
+		}
+		h.counts[i] = c
+		h.totalCount = h.totalCount + c
+		i++
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		return ErrInvalidEncoding
+	}
+	if (h.totalCount == 0) != (n == 0) {
+		return ErrInvalidEncoding
+	}
+	h.min = min
+	h.max = max
+	return nil
+}
@@ -0,0 +1,191 @@
+package gostats
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	. "github.com/onsi/gomega"
+	"math"
+	"testing"
+)
+
+func TestHdrHistogram(t *testing.T) {
+
+	const hour = 3600 * 1000 * 1000
+
+	t.Run("Equivalent values", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		h := NewHdrHistogram(1, hour, 3)
+		g.Expect(h.LowestEquivalentValue(1000)).To(Equal(int64(1000)))
+		g.Expect(h.HighestEquivalentValue(1000)).To(Equal(int64(1000)))
+		g.Expect(h.LowestEquivalentValue(10007)).To(Equal(int64(10000)))
+		g.Expect(h.HighestEquivalentValue(10007)).To(Equal(int64(10007)))
+		g.Expect(h.LowestEquivalentValue(10008)).To(Equal(int64(10008)))
+		h.CleanPool()
+	})
+
+	t.Run("Quantiles", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		h := NewHdrHistogram(1, hour, 3)
+		g.Expect(h.ValueAtQuantile(0.5)).To(Equal(int64(0)))
+		g.Expect(math.IsNaN(h.Mean())).To(BeTrue())
+		for v := int64(1); v <= 1000000; v++ {
+			g.Expect(h.RecordValue(v)).To(Succeed())
+		}
+		g.Expect(h.TotalCount()).To(Equal(int64(1000000)))
+		for _, q := range []float64{0.01, 0.25, 0.5, 0.9, 0.99, 0.999} {
+			g.Expect(float64(h.ValueAtQuantile(q))).To(BeNumerically("~", q*1000000, q*1000))
+		}
+		g.Expect(h.ValueAtQuantile(0)).To(Equal(int64(1)))
+		g.Expect(h.ValueAtQuantile(1)).To(Equal(h.HighestEquivalentValue(1000000)))
+		g.Expect(h.Mean()).To(BeNumerically("~", 500000.5, 500))
+		g.Expect(h.StdDev()).To(BeNumerically("~", 288675.1, 289))
+		min, max := h.Bounds()
+		g.Expect([]int64{min, max}).To(Equal([]int64{1, 1000000}))
+		h.CleanPool()
+	})
+
+	t.Run("Out of range", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		h := NewHdrHistogram(1, hour, 3)
+		g.Expect(h.RecordValue(-1)).To(Equal(ErrValueOutOfRange))
+		g.Expect(h.RecordValue(100 * hour)).To(Equal(ErrValueOutOfRange))
+		g.Expect(h.RecordValue(hour)).To(Succeed())
+		g.Expect(h.TotalCount()).To(Equal(int64(1)))
+		g.Expect(h.RecordValues(10, -1)).To(Equal(ErrNegativeRecordCount))
+		g.Expect(h.RecordValues(10, 0)).To(Succeed())
+		g.Expect(h.TotalCount()).To(Equal(int64(1)))
+		min, _ := h.Bounds()
+		g.Expect(min).To(Equal(int64(hour)))
+		h.CleanPool()
+	})
+
+	t.Run("Buckets", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		h := NewHdrHistogram(1, hour, 2)
+		g.Expect(h.RecordValues(5, 3)).To(Succeed())
+		g.Expect(h.RecordValue(1000)).To(Succeed())
+		g.Expect(h.RecordValue(1001)).To(Succeed())
+		g.Expect(h.Buckets(nil)).To(Equal([]HdrBucket{
+			{From: 5, To: 5, Count: 3},
+			{From: 1000, To: 1003, Count: 2},
+		}))
+		h.CleanPool()
+	})
+
+	t.Run("Merge & Subtract", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		h := NewHdrHistogram(1, hour, 3)
+		for v := int64(1); v <= 1000; v++ {
+			g.Expect(h.RecordValue(v * 1000)).To(Succeed())
+		}
+		snapshot := h.Clone()
+		for v := int64(1001); v <= 2000; v++ {
+			g.Expect(h.RecordValue(v * 1000)).To(Succeed())
+		}
+
+		interval := h.Clone()
+		g.Expect(interval.Subtract(snapshot)).To(Succeed())
+		g.Expect(interval.TotalCount()).To(Equal(int64(1000)))
+		min, max := interval.Bounds()
+		g.Expect(min).To(Equal(interval.LowestEquivalentValue(1001000)))
+		g.Expect(max).To(Equal(interval.HighestEquivalentValue(2000000)))
+		g.Expect(snapshot.Subtract(h)).To(Equal(ErrNegativeCount))
+		g.Expect(snapshot.TotalCount()).To(Equal(int64(1000)))
+
+		g.Expect(interval.Merge(snapshot)).To(Succeed())
+		g.Expect(interval.Buckets(nil)).To(Equal(h.Buckets(nil)))
+
+		// different geometries
+		coarse := NewHdrHistogram(1, hour, 2)
+		g.Expect(coarse.Merge(h)).To(Succeed())
+		g.Expect(coarse.TotalCount()).To(Equal(h.TotalCount()))
+		g.Expect(float64(coarse.ValueAtQuantile(0.5))).To(BeNumerically("~", 1000000, 10000))
+		g.Expect(coarse.Subtract(snapshot)).To(Succeed())
+		g.Expect(coarse.TotalCount()).To(Equal(int64(1000)))
+		small := NewHdrHistogram(1, 1000, 3)
+		g.Expect(small.Merge(h)).To(Equal(ErrValueOutOfRange))
+		g.Expect(small.TotalCount()).To(Equal(int64(0)))
+
+		small.CleanPool()
+		coarse.CleanPool()
+		interval.CleanPool()
+		snapshot.CleanPool()
+		h.CleanPool()
+	})
+
+	t.Run("Binary encoding", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		h := NewHdrHistogram(1, hour, 3)
+		for v := int64(1); v <= 100000; v += 7 {
+			g.Expect(h.RecordValues(v, v%5+1)).To(Succeed())
+		}
+		data, err := h.MarshalBinary()
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(len(data)).To(BeNumerically("<", 8*len(h.counts)/10))
+
+		decoded := NewHdrHistogram(10, 1000, 1)
+		g.Expect(decoded.UnmarshalBinary(data)).To(Succeed())
+		g.Expect(decoded.SignificantFigures()).To(Equal(3))
+		g.Expect(decoded.TotalCount()).To(Equal(h.TotalCount()))
+		g.Expect(decoded.Buckets(nil)).To(Equal(h.Buckets(nil)))
+		min, max := decoded.Bounds()
+		g.Expect([]int64{min, max}).To(Equal([]int64{1, 99996}))
+
+		g.Expect(decoded.UnmarshalBinary(data[:len(data)/2])).To(Equal(ErrInvalidEncoding))
+		data[0] = 2
+		g.Expect(decoded.UnmarshalBinary(data)).To(Equal(ErrUnsupportedVersion))
+
+		// encode builds a payload from the header and the counts, as MarshalBinary does.
+		encode := func(values ...int64) []byte {
+			var payload bytes.Buffer
+			var b [binary.MaxVarintLen64]byte
+			for _, v := range values {
+				payload.Write(b[:binary.PutVarint(b[:], v)])
+			}
+			var data bytes.Buffer
+			data.WriteByte(hdrHistogramEncodingVersion)
+			w := zlib.NewWriter(&data)
+			_, _ = w.Write(payload.Bytes())
+			_ = w.Close()
+			return data.Bytes()
+		}
+		g.Expect(decoded.UnmarshalBinary(encode(1, hour, 3, math.MaxInt64, 0, 0))).To(Succeed())
+		g.Expect(decoded.UnmarshalBinary(encode(1, hour, 3, 5, 5, 1, 1))).To(Succeed())
+		for _, header := range [][]int64{
+			{0, hour, 3, math.MaxInt64, 0, 0},
+			{1, hour, 6, math.MaxInt64, 0, 0},
+			{1, hour, 1 << 40, math.MaxInt64, 0, 0},
+			{math.MaxInt64/2 + 1, math.MaxInt64, 1, math.MaxInt64, 0, 0},
+			{1 << 50, math.MaxInt64, 5, math.MaxInt64, 0, 0},
+			{1, hour, 3, 5, 5, 0},
+			{1, hour, 3, 5, 4, 1, 1},
+			{1, hour, 3, -1, 5, 1, 1},
+			{1, hour, 3, 5, 5, 1 << 40},
+			{1, hour, 3, 5, 5, 1, 0},
+		} {
+			g.Expect(decoded.UnmarshalBinary(encode(header...))).To(Equal(ErrInvalidEncoding), "%v", header)
+		}
+		g.Expect(decoded.UnmarshalBinary(encode(1, hour, 3, 1, 2, 2, math.MaxInt64, 1))).To(Equal(ErrInvalidEncoding))
+		decoded.CleanPool()
+		h.CleanPool()
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(func() { NewHdrHistogram(0, 100, 3) }).To(Panic())
+		g.Expect(func() { NewHdrHistogram(10, 15, 3) }).To(Panic())
+		g.Expect(func() { NewHdrHistogram(1, 100, 6) }).To(Panic())
+		g.Expect(func() { NewHdrHistogram(math.MaxInt64/2+1, math.MaxInt64, 1) }).To(Panic())
+		g.Expect(func() { NewHdrHistogram(1<<50, math.MaxInt64, 5) }).To(Panic())
+	})
+}
+
+func BenchmarkHdrHistogram(b *testing.B) {
+	h := NewHdrHistogram(1, 3600*1000*1000, 3)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = h.RecordValue(int64(i%100000) * 37)
+	}
+	h.CleanPool()
+}
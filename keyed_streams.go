@@ -0,0 +1,226 @@
+package gostats
+
+import (
+	"sync"
+	"time"
+)
+
+var KeyedStreamsPool = sync.Pool{
+	New: func() interface{} { return new(KeyedStreams) },
+}
+
+// KeyedStreamsOptions configures a KeyedStreams.
+type KeyedStreamsOptions struct {
+	// MaxKeys is the maximum number of keys, 0 meaning no limit.
+	MaxKeys int
+	// TTL is the duration after which a key that has not been appended to can be evicted, 0 meaning never.
+	// Expiry is not automatic: idle keys are only evicted when the caller runs Evict, typically from a ticker,
+	// or when a new key would exceed MaxKeys. Until then, they are still returned by Get, Range and Snapshot.
+	TTL time.Duration
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+}
+
+// KeyedStreams holds a SampleStream per key, such as an instrument symbol, created from SampleStreamPool on
+// the first Append to the key. It is safe for concurrent use: Appends to different keys do not wait for each
+// other.
+//
+// Keys idle for longer than the TTL are evicted by Evict, which the caller must run periodically, or when a new
+// key would exceed MaxKeys, and their stream is put back in the pool.
+type KeyedStreams struct {
+	mu      sync.RWMutex
+	entries map[string]*keyedEntry
+	options KeyedStreamsOptions
+}
+
+type keyedEntry struct {
+	mu         sync.Mutex
+	stream     *SampleStream
+	lastAppend time.Time
+	// evicted is set once the stream has been put back in the pool.
+	evicted bool
+}
+
+// NewKeyedStreams returns an empty KeyedStreams.
+func NewKeyedStreams(options KeyedStreamsOptions) *KeyedStreams {
+	k := KeyedStreamsPool.Get().(*KeyedStreams)
+	k.initEmptyValues(options)
+	return k
+}
+
+// CleanPool puts the streams and k back in their pools. k must not be used concurrently anymore.
+func (k *KeyedStreams) CleanPool() {
+	for key, entry := range k.entries {
+		entry.stream.CleanPool()
+		delete(k.entries, key)
+	}
+	KeyedStreamsPool.Put(k)
+}
+
+func (k *KeyedStreams) initEmptyValues(options KeyedStreamsOptions) {
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	k.options = options
+	if k.entries == nil {
+		k.entries = make(map[string]*keyedEntry)
+	}
+	for key := range k.entries {
+		delete(k.entries, key)
+	}
+}
+
+// Append adds a value to the stream of the key, creating it if needed. It fails with ErrTooManyKeys if the key
+// is new and there are already MaxKeys keys that are not idle.
+func (k *KeyedStreams) Append(key string, value float64) error {
+	return k.update(key, func(s *SampleStream) { s.Append(value) })
+}
+
+// AppendMany adds the values to the stream of the key, see Append.
+func (k *KeyedStreams) AppendMany(key string, values []float64) error {
+	return k.update(key, func(s *SampleStream) { s.AppendMany(values) })
+}
+
+func (k *KeyedStreams) update(key string, fn func(s *SampleStream)) error {
+	for {
+		k.mu.RLock()
+		entry := k.entries[key]
+		k.mu.RUnlock()
+
+		if entry == nil {
+			var err error
+			if entry, err = k.create(key); err != nil {
+				return err
+			}
+		}
+
+		entry.mu.Lock()
+		// The entry may have been evicted since it has been looked up.
+		if !entry.evicted {
+			fn(entry.stream)
+			entry.lastAppend = k.options.Now()
+			entry.mu.Unlock()
+			return nil
+		}
+		entry.mu.Unlock()
+	}
+}
+
+// create returns the entry of the key, creating it if it still does not exist.
+func (k *KeyedStreams) create(key string) (*keyedEntry, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if entry := k.entries[key]; entry != nil {
+		return entry, nil
+	}
+	if k.options.MaxKeys > 0 && len(k.entries) >= k.options.MaxKeys {
+		k.evict()
+		if len(k.entries) >= k.options.MaxKeys {
+			return nil, ErrTooManyKeys
+		}
+	}
+	entry := &keyedEntry{stream: NewSampleStream(), lastAppend: k.options.Now()}
+	k.entries[key] = entry
+	return entry, nil
+}
+
+// Evict removes the keys that have not been appended to for longer than the TTL, putting their streams back
+// in the pool, and returns the number of evicted keys.
+func (k *KeyedStreams) Evict() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.evict()
+}
+
+func (k *KeyedStreams) evict() int {
+	if k.options.TTL <= 0 {
+		return 0
+	}
+	deadline := k.options.Now().Add(-k.options.TTL)
+	evicted := 0
+	for key, entry := range k.entries {
+		entry.mu.Lock()
+		if entry.lastAppend.Before(deadline) {
+			k.remove(key, entry)
+			evicted++
+		}
+		entry.mu.Unlock()
+	}
+	return evicted
+}
+
+// remove deletes the entry, which must be locked, as well as k.
+func (k *KeyedStreams) remove(key string, entry *keyedEntry) {
+	delete(k.entries, key)
+	entry.stream.CleanPool()
+	entry.stream = nil
+	entry.evicted = true
+}
+
+// Remove removes the key, putting its stream back in the pool. It returns false if the key does not exist.
+func (k *KeyedStreams) Remove(key string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	entry := k.entries[key]
+	if entry == nil {
+		return false
+	}
+	entry.mu.Lock()
+	k.remove(key, entry)
+	entry.mu.Unlock()
+	return true
+}
+
+// Len returns the number of keys.
+func (k *KeyedStreams) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.entries)
+}
+
+// Get returns a copy of the stream of the key, taken from the pool, or false if the key does not exist.
+func (k *KeyedStreams) Get(key string) (*SampleStream, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	entry := k.entries[key]
+	if entry == nil {
+		return nil, false
+	}
+	return entry.copy(), true
+}
+
+// copy returns a copy of the stream of the entry, taken from the pool.
+func (e *keyedEntry) copy() *SampleStream {
+	s := NewSampleStream()
+	e.mu.Lock()
+	s.Merge(e.stream)
+	e.mu.Unlock()
+	return s
+}
+
+// Range calls fn for each key, in no particular order, until it returns false. The stream is locked during the
+// call: fn must not keep it, nor call the methods of k.
+func (k *KeyedStreams) Range(fn func(key string, s *SampleStream) bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for key, entry := range k.entries {
+		entry.mu.Lock()
+		more := fn(key, entry.stream)
+		entry.mu.Unlock()
+		if !more {
+			return
+		}
+	}
+}
+
+// Snapshot returns a copy of the streams of all the keys, taken from the pool. No key can be added or removed
+// while the snapshot is taken, but each stream is copied at a different time.
+func (k *KeyedStreams) Snapshot() map[string]*SampleStream {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	snapshot := make(map[string]*SampleStream, len(k.entries))
+	for key, entry := range k.entries {
+		snapshot[key] = entry.copy()
+	}
+	return snapshot
+}
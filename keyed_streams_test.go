@@ -0,0 +1,127 @@
+package gostats
+
+import (
+	"fmt"
+	. "github.com/onsi/gomega"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedStreams(t *testing.T) {
+
+	t.Run("Append & Get", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		k := NewKeyedStreams(KeyedStreamsOptions{})
+		g.Expect(k.Append("EURUSD", 1)).To(Succeed())
+		g.Expect(k.AppendMany("EURUSD", []float64{2, 3})).To(Succeed())
+		g.Expect(k.Append("GBPUSD", -1)).To(Succeed())
+		g.Expect(k.Len()).To(Equal(2))
+
+		s, ok := k.Get("EURUSD")
+		g.Expect(ok).To(BeTrue())
+		g.Expect(s.Len()).To(Equal(3))
+		g.Expect(s.Mean()).To(Equal(2.0))
+		s.Append(100)
+		s.CleanPool()
+		s, _ = k.Get("EURUSD")
+		g.Expect(s.Len()).To(Equal(3))
+		s.CleanPool()
+
+		_, ok = k.Get("USDJPY")
+		g.Expect(ok).To(BeFalse())
+		g.Expect(k.Remove("GBPUSD")).To(BeTrue())
+		g.Expect(k.Remove("GBPUSD")).To(BeFalse())
+		g.Expect(k.Len()).To(Equal(1))
+		k.CleanPool()
+	})
+
+	t.Run("Range & Snapshot", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		k := NewKeyedStreams(KeyedStreamsOptions{})
+		for i := 0; i < 10; i++ {
+			g.Expect(k.AppendMany(fmt.Sprint("key", i), []float64{float64(i), float64(i)})).To(Succeed())
+		}
+		sums := map[string]float64{}
+		k.Range(func(key string, s *SampleStream) bool {
+			sums[key] = s.Sum()
+			return true
+		})
+		g.Expect(sums).To(HaveLen(10))
+		g.Expect(sums["key3"]).To(Equal(6.0))
+
+		calls := 0
+		k.Range(func(key string, s *SampleStream) bool {
+			calls++
+			return false
+		})
+		g.Expect(calls).To(Equal(1))
+
+		snapshot := k.Snapshot()
+		g.Expect(snapshot).To(HaveLen(10))
+		g.Expect(snapshot["key7"].Mean()).To(Equal(7.0))
+		for _, s := range snapshot {
+			s.CleanPool()
+		}
+		k.CleanPool()
+	})
+
+	t.Run("TTL & MaxKeys", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		now := time.Unix(0, 0)
+		k := NewKeyedStreams(KeyedStreamsOptions{
+			MaxKeys: 2,
+			TTL:     time.Minute,
+			Now:     func() time.Time { return now },
+		})
+		g.Expect(k.Append("a", 1)).To(Succeed())
+		now = now.Add(30 * time.Second)
+		g.Expect(k.Append("b", 1)).To(Succeed())
+		g.Expect(k.Append("c", 1)).To(Equal(ErrTooManyKeys))
+
+		now = now.Add(45 * time.Second)
+		g.Expect(k.Append("a", 2)).To(Succeed())
+		g.Expect(k.Evict()).To(Equal(0))
+		now = now.Add(16 * time.Second)
+		// b is idle, a is not
+		g.Expect(k.Append("c", 1)).To(Succeed())
+		_, ok := k.Get("b")
+		g.Expect(ok).To(BeFalse())
+		s, _ := k.Get("a")
+		g.Expect(s.Len()).To(Equal(2))
+		s.CleanPool()
+
+		now = now.Add(time.Hour)
+		// Expiry is not automatic: idle keys stay until Evict.
+		s, ok = k.Get("a")
+		g.Expect(ok).To(BeTrue())
+		s.CleanPool()
+		g.Expect(k.Snapshot()).To(HaveLen(2))
+		g.Expect(k.Evict()).To(Equal(2))
+		g.Expect(k.Len()).To(Equal(0))
+		k.CleanPool()
+	})
+
+	t.Run("Concurrent appends", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		k := NewKeyedStreams(KeyedStreamsOptions{TTL: time.Nanosecond})
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					_ = k.Append(fmt.Sprint("key", i%10), float64(w))
+					if i%100 == 0 {
+						k.Evict()
+						k.Snapshot()
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		g.Expect(k.Len()).To(BeNumerically("<=", 10))
+		k.CleanPool()
+	})
+}
@@ -0,0 +1,108 @@
+package gostats
+
+import (
+	"math"
+	"sync"
+)
+
+var PairStreamPool = sync.Pool{
+	New: func() interface{} { return new(PairStream) },
+}
+
+// PairStream processes two series side by side, like two SampleStream, and also maintains their co-moment
+// so that the covariance, the Pearson correlation, the beta and the regression slope are available in O(1)
+// per Append, without any allocation.
+//
+// X is the explanatory series (the benchmark or the market feed), Y the explained one.
+type PairStream struct {
+	x SampleStream
+	y SampleStream
+	// streaming
+	_coMoment float64
+}
+
+func NewPairStream() *PairStream {
+	p := PairStreamPool.Get().(*PairStream)
+	p.initEmptyValues()
+	return p
+}
+
+func (p *PairStream) CleanPool() {
+	PairStreamPool.Put(p)
+}
+
+func (p *PairStream) initEmptyValues() {
+	p.x.initEmptyValues()
+	p.y.initEmptyValues()
+	p._coMoment = 0
+}
+
+// AppendMany appends the pairs (xs[i], ys[i]). It panics if xs and ys have different lengths.
+func (p *PairStream) AppendMany(xs, ys []float64) {
+	if len(xs) != len(ys) {
+		panic(ErrMismatchedSamples)
+	}
+	for i := range xs {
+		p.Append(xs[i], ys[i])
+	}
+}
+
+func (p *PairStream) Append(x, y float64) {
+	// The co-moment update needs the mean of X before, and the mean of Y after the new pair.
+	dx := x - p.x._prevMean
+	p.x.Append(x)
+	p.y.Append(y)
+	p._coMoment = p._coMoment + dx*(y-p.y._prevMean)
+}
+
+// X returns the statistics of the explanatory series.
+func (p *PairStream) X() *SampleStream {
+	return &p.x
+}
+
+// Y returns the statistics of the explained series.
+func (p *PairStream) Y() *SampleStream {
+	return &p.y
+}
+
+// Len returns the number of pairs.
+func (p *PairStream) Len() int {
+	return int(p.x._count)
+}
+
+// Covariance returns the sample covariance of X and Y.
+func (p *PairStream) Covariance() float64 {
+	return p._coMoment / (p.x._count - 1)
+}
+
+// CovariancePopulation returns the population covariance of X and Y.
+func (p *PairStream) CovariancePopulation() float64 {
+	return p._coMoment / p.x._count
+}
+
+// Correlation returns the Pearson correlation of X and Y. Like Sample.Correlation, it is 0 when one of the
+// series is constant.
+func (p *PairStream) Correlation() float64 {
+	if p.x._count == 0 {
+		return math.NaN()
+	}
+	if p.x._prevVariance == 0 || p.y._prevVariance == 0 {
+		return 0
+	}
+	return p._coMoment / math.Sqrt(p.x._prevVariance*p.y._prevVariance)
+}
+
+// Slope returns the slope of the least squares regression of Y on X.
+func (p *PairStream) Slope() float64 {
+	return p._coMoment / p.x._prevVariance
+}
+
+// Intercept returns the intercept of the least squares regression of Y on X.
+func (p *PairStream) Intercept() float64 {
+	return p.y._prevMean - p.Slope()*p.x._prevMean
+}
+
+// Beta returns the beta of Y against the benchmark X, Cov(X, Y) / Var(X), which is also the regression Slope.
+func (p *PairStream) Beta() float64 {
+	return p.Slope()
+}
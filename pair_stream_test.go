@@ -0,0 +1,70 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"testing"
+)
+
+func TestPairStream(t *testing.T) {
+
+	xs := []float64{1, 2, 3.5, 3.7, 8, 12}
+	ys := []float64{0.5, 1, 2.1, 3.4, 3.4, 4}
+
+	t.Run("Against Sample", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p := NewPairStream()
+		p.AppendMany(xs, ys)
+		sx := NewSampleWithValue(xs, true)
+		sy := NewSampleWithValue(ys, true)
+
+		g.Expect(p.Len()).To(Equal(6))
+		g.Expect(p.X().Mean()).To(Equal(sx.Mean()))
+		g.Expect(p.Y().Mean()).To(Equal(sy.Mean()))
+		g.Expect(p.CovariancePopulation()).To(BeNumerically("~", sy.CovariancePopulation(sx), 1e-12))
+		g.Expect(p.Covariance()).To(BeNumerically("~", sy.CovariancePopulation(sx)*6/5, 1e-12))
+		g.Expect(p.Correlation()).To(BeNumerically("~", sy.Correlation(sx, CorrelationPearson), 1e-12))
+		g.Expect(p.Beta()).To(BeNumerically("~", sy.CovariancePopulation(sx)/sx.PopulationVariance(), 1e-12))
+		p.CleanPool()
+	})
+
+	t.Run("Regression", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p := NewPairStream()
+		for i := 0; i < 10; i++ {
+			p.Append(float64(i), 3*float64(i)-2)
+		}
+		g.Expect(p.Slope()).To(BeNumerically("~", 3, 1e-12))
+		g.Expect(p.Intercept()).To(BeNumerically("~", -2, 1e-12))
+		g.Expect(p.Correlation()).To(BeNumerically("~", 1, 1e-12))
+		p.CleanPool()
+	})
+
+	t.Run("Degenerate", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p := NewPairStream()
+		g.Expect(math.IsNaN(p.Correlation())).To(BeTrue())
+		p.Append(1, 2)
+		p.Append(1, 3)
+		g.Expect(p.Correlation()).To(Equal(0.0))
+		g.Expect(func() { p.AppendMany([]float64{1}, nil) }).To(Panic())
+		p.CleanPool()
+	})
+
+}
+
+func BenchmarkPairStream(b *testing.B) {
+	points := make([]float64, 300)
+	for i := range points {
+		points[i] = float64(i)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := NewPairStream()
+		p.AppendMany(points, points)
+		p.Correlation()
+		p.Beta()
+		p.CleanPool()
+	}
+}
@@ -0,0 +1,132 @@
+package gostats
+
+import (
+	"math"
+)
+
+// PerformanceOptions configures the computation of a Performance.
+type PerformanceOptions struct {
+	// RiskFreeRate is the annual risk-free rate, used by the Sharpe ratio. Its per-period value is
+	// RiskFreeRate / PeriodsPerYear.
+	RiskFreeRate float64
+	// PeriodsPerYear is the number of returns in a year, such as 252 for daily returns or 12 for monthly
+	// returns. 0 means 1: the ratios are not annualized.
+	PeriodsPerYear float64
+	// MinimumAcceptableReturn is the per-period return below which a return is a loss, used by the downside
+	// deviation, the Sortino ratio and the Omega ratio.
+	MinimumAcceptableReturn float64
+}
+
+// Performance holds the risk-adjusted performance ratios of a series of returns. Ratios with a zero
+// denominator are ±Inf, or NaN if their numerator is also zero.
+type Performance struct {
+	// N is the number of returns.
+	N int
+	// MeanReturn is the arithmetic mean of the returns, per period.
+	MeanReturn float64
+	// AnnualizedReturn is the compounded annual growth rate of the returns.
+	AnnualizedReturn float64
+	// Volatility is the annualized sample standard deviation of the returns.
+	Volatility float64
+	// DownsideDeviation is the annualized root mean square of the returns below MinimumAcceptableReturn,
+	// the other returns counting as 0.
+	DownsideDeviation float64
+	// MaxDrawdown is the compounded maximum drawdown, see DrawdownCompounded.
+	MaxDrawdown float64
+	// Sharpe is the annualized mean excess return over the risk-free rate, divided by the volatility.
+	Sharpe float64
+	// Sortino is the annualized mean excess return over MinimumAcceptableReturn, divided by the downside
+	// deviation.
+	Sortino float64
+	// Calmar is the annualized return divided by the maximum drawdown.
+	Calmar float64
+	// Omega is the sum of the gains above MinimumAcceptableReturn divided by the sum of the losses below it.
+	Omega float64
+	// TrackingError is the annualized standard deviation of the active returns, the differences between the
+	// returns and the benchmark returns. It is NaN without benchmark.
+	TrackingError float64
+	// InformationRatio is the annualized mean active return divided by the tracking error. It is NaN without
+	// benchmark.
+	InformationRatio float64
+}
+
+// Performance computes the risk-adjusted performance ratios of the Sample, its values being returns in their
+// original order, such as 0.01 for 1%. benchmark is optional: if not nil, it holds the returns of the
+// benchmark over the same periods, and gives the tracking error and the information ratio.
+//
+// It panics if a Sample has no original values, see PerformanceE. If the Sample is empty or the benchmark
+// does not have the same length, all the ratios are NaN.
+func (s *Sample) Performance(options PerformanceOptions, benchmark *Sample) *Performance {
+	performance, err := s.PerformanceE(options, benchmark)
+	switch err {
+	case nil:
+		return performance
+	case ErrSampleSize, ErrMismatchedSamples:
+		nan := math.NaN()
+		return &Performance{N: s.nb, MeanReturn: nan, AnnualizedReturn: nan, Volatility: nan,
+			DownsideDeviation: nan, MaxDrawdown: nan, Sharpe: nan, Sortino: nan, Calmar: nan, Omega: nan,
+			TrackingError: nan, InformationRatio: nan}
+	}
+	panic(err)
+}
+
+// PerformanceE is like Performance, but returns an error instead of panicking.
+func (s *Sample) PerformanceE(options PerformanceOptions, benchmark *Sample) (*Performance, error) {
+	if benchmark != nil {
+		if err := s.validatePair(benchmark); err != nil {
+			return nil, err
+		}
+	} else if !s.withOriginal {
+		return nil, ErrNoOriginal
+	} else if s.nb == 0 {
+		return nil, ErrSampleSize
+	}
+
+	periods := options.PeriodsPerYear
+	if periods == 0 {
+		periods = 1
+	}
+	annualize := math.Sqrt(periods)
+	mar := options.MinimumAcceptableReturn
+
+	p := &Performance{
+		N:                s.nb,
+		MeanReturn:       s.Mean(),
+		Volatility:       s.StdDev() * annualize,
+		TrackingError:    math.NaN(),
+		InformationRatio: math.NaN(),
+	}
+
+	var drawdown drawdownTracker
+	drawdown.init(DrawdownCompounded)
+	downside, gains, losses := 0.0, 0.0, 0.0
+	for _, value := range s.original {
+		drawdown.append(value)
+		if d := value - mar; d < 0 {
+			downside += d * d
+			losses -= d
+		} else {
+			gains += d
+		}
+	}
+	downside = math.Sqrt(downside / float64(s.nb))
+
+	p.AnnualizedReturn = math.Pow(drawdown.equity, periods/float64(s.nb)) - 1
+	p.DownsideDeviation = downside * annualize
+	p.MaxDrawdown = drawdown.maxDrawdown
+	p.Sharpe = (p.MeanReturn - options.RiskFreeRate/periods) / s.StdDev() * annualize
+	p.Sortino = (p.MeanReturn - mar) / downside * annualize
+	p.Calmar = p.AnnualizedReturn / p.MaxDrawdown
+	p.Omega = gains / losses
+
+	if benchmark != nil {
+		active := NewSampleFromPool(false)
+		for i, value := range s.original {
+			active.Append(value - benchmark.original[i])
+		}
+		p.TrackingError = active.StdDev() * annualize
+		p.InformationRatio = active.Mean() / active.StdDev() * annualize
+		active.BackToPool()
+	}
+	return p, nil
+}
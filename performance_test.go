@@ -0,0 +1,74 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"testing"
+)
+
+func TestPerformance(t *testing.T) {
+
+	returns := []float64{0.1, -0.05, 0.02, -0.03, 0.04}
+	benchmark := []float64{0.05, -0.02, 0.01, 0, 0.02}
+	options := PerformanceOptions{RiskFreeRate: 0.012, PeriodsPerYear: 12}
+
+	t.Run("Ratios", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue(returns, true)
+		p := s.Performance(options, nil)
+		g.Expect(p.N).To(Equal(5))
+		g.Expect(p.MeanReturn).To(BeNumerically("~", 0.016, 1e-12))
+		g.Expect(p.Volatility).To(BeNumerically("~", s.StdDev()*math.Sqrt(12), 1e-12))
+		g.Expect(p.Sharpe).To(BeNumerically("~", 0.8745699064377065, 1e-12))
+		g.Expect(p.DownsideDeviation).To(BeNumerically("~", 0.09033271832508973, 1e-12))
+		g.Expect(p.Sortino).To(BeNumerically("~", 2.125475725296228, 1e-12))
+		g.Expect(p.AnnualizedReturn).To(BeNumerically("~", 0.1902869876210247, 1e-12))
+		g.Expect(p.MaxDrawdown).To(BeNumerically("~", 0.06007, 1e-12))
+		g.Expect(p.Calmar).To(BeNumerically("~", 3.167754080589714, 1e-9))
+		g.Expect(p.Omega).To(BeNumerically("~", 2, 1e-12))
+		g.Expect(math.IsNaN(p.TrackingError)).To(BeTrue())
+		g.Expect(math.IsNaN(p.InformationRatio)).To(BeTrue())
+	})
+
+	t.Run("Benchmark", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue(returns, true)
+		p := s.Performance(options, NewSampleWithValue(benchmark, true))
+		g.Expect(p.TrackingError).To(BeNumerically("~", 0.11899579824514814, 1e-12))
+		g.Expect(p.InformationRatio).To(BeNumerically("~", 0.4033755872716886, 1e-12))
+		g.Expect(p.Sharpe).To(BeNumerically("~", 0.8745699064377065, 1e-12))
+	})
+
+	t.Run("Minimum acceptable return", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue(returns, true)
+		p := s.Performance(PerformanceOptions{MinimumAcceptableReturn: 0.03}, nil)
+		// gains 0.07 + 0.01, losses 0.08 + 0.01 + 0.06
+		g.Expect(p.Omega).To(BeNumerically("~", 0.08/0.15, 1e-12))
+		g.Expect(p.DownsideDeviation).To(BeNumerically("~", math.Sqrt((0.0064+0.0001+0.0036)/5), 1e-12))
+		g.Expect(p.Sortino).To(BeNumerically("~", -0.014/p.DownsideDeviation, 1e-12))
+	})
+
+	t.Run("No losses", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p := NewSampleWithValue([]float64{0.01, 0.02}, true).Performance(PerformanceOptions{}, nil)
+		g.Expect(p.MaxDrawdown).To(Equal(0.0))
+		g.Expect(math.IsInf(p.Calmar, 1)).To(BeTrue())
+		g.Expect(math.IsInf(p.Omega, 1)).To(BeTrue())
+		g.Expect(math.IsInf(p.Sortino, 1)).To(BeTrue())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		_, err := NewSampleWithValue(returns, false).PerformanceE(options, nil)
+		g.Expect(err).To(Equal(ErrNoOriginal))
+		g.Expect(func() { NewSampleWithValue(returns, false).Performance(options, nil) }).To(Panic())
+
+		_, err = NewSampleWithValue(nil, true).PerformanceE(options, nil)
+		g.Expect(err).To(Equal(ErrSampleSize))
+		_, err = NewSampleWithValue(returns, true).PerformanceE(options, NewSampleWithValue(benchmark[:3], true))
+		g.Expect(err).To(Equal(ErrMismatchedSamples))
+		p := NewSampleWithValue(returns, true).Performance(options, NewSampleWithValue(benchmark[:3], true))
+		g.Expect(math.IsNaN(p.Sharpe)).To(BeTrue())
+	})
+}
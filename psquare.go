@@ -0,0 +1,265 @@
+package gostats
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+var PSquarePool = sync.Pool{
+	New: func() interface{} { return new(PSquare) },
+}
+
+var PSquareMultiPool = sync.Pool{
+	New: func() interface{} { return new(PSquareMulti) },
+}
+
+// PSquare estimates a single quantile of a stream with the P² algorithm (Jain & Chlamtac, 1985), using five
+// markers and no other memory: the markers are moved towards their desired positions after every Append, their
+// heights being adjusted with a piecewise-parabolic interpolation.
+//
+// Until five values have been appended, the quantile is computed exactly. The estimate is good for smooth
+// distributions, but it has no error guarantee: use a TDigest or a DDSketch when the accuracy matters.
+type PSquare struct {
+	p       float64
+	markers pSquareMarkers
+}
+
+// NewPSquare returns a PSquare estimating the quantile p. It panics unless 0 < p < 1.
+func NewPSquare(p float64) *PSquare {
+	if !(p > 0 && p < 1) {
+		panic("PSquare quantile must be in (0, 1)")
+	}
+	s := PSquarePool.Get().(*PSquare)
+	s.p = p
+	s.markers.init([]float64{p})
+	return s
+}
+
+func (s *PSquare) CleanPool() {
+	PSquarePool.Put(s)
+}
+
+func (s *PSquare) Append(value float64) {
+	s.markers.append(value)
+}
+
+func (s *PSquare) AppendMany(values []float64) {
+	for _, value := range values {
+		s.markers.append(value)
+	}
+}
+
+// P returns the estimated quantile.
+func (s *PSquare) P() float64 {
+	return s.p
+}
+
+// Len returns the number of appended values.
+func (s *PSquare) Len() int {
+	return s.markers.count
+}
+
+// Value returns the current estimate of the quantile, or NaN if no value has been appended.
+func (s *PSquare) Value() float64 {
+	return s.markers.quantile(0)
+}
+
+// PSquareMulti estimates several quantiles of a stream at once with the extended P² algorithm (Raatikainen,
+// 1987): m quantiles use 2m + 3 markers, placed at the quantiles, at the middle points between them, and at
+// the minimum and maximum. See PSquare.
+type PSquareMulti struct {
+	ps      []float64
+	markers pSquareMarkers
+}
+
+// NewPSquareMulti returns a PSquareMulti estimating the quantiles ps. It panics if ps is empty, or if a
+// quantile is not in (0, 1) or appears twice.
+func NewPSquareMulti(ps ...float64) *PSquareMulti {
+	if len(ps) == 0 {
+		panic("PSquareMulti needs at least one quantile")
+	}
+	s := PSquareMultiPool.Get().(*PSquareMulti)
+	s.ps = append(s.ps[:0], ps...)
+	sort.Float64s(s.ps)
+	for i, p := range s.ps {
+		if !(p > 0 && p < 1) {
+			panic("PSquareMulti quantiles must be in (0, 1)")
+		}
+		if i > 0 && p == s.ps[i-1] {
+			panic("PSquareMulti quantiles must be distinct")
+		}
+	}
+	s.markers.init(s.ps)
+	return s
+}
+
+func (s *PSquareMulti) CleanPool() {
+	PSquareMultiPool.Put(s)
+}
+
+func (s *PSquareMulti) Append(value float64) {
+	s.markers.append(value)
+}
+
+func (s *PSquareMulti) AppendMany(values []float64) {
+	for _, value := range values {
+		s.markers.append(value)
+	}
+}
+
+// Quantiles returns the estimated quantiles, in increasing order. The slice must not be modified.
+func (s *PSquareMulti) Quantiles() []float64 {
+	return s.ps
+}
+
+// Len returns the number of appended values.
+func (s *PSquareMulti) Len() int {
+	return s.markers.count
+}
+
+// Quantile returns the current estimate of the quantile p, which must be one of the estimated quantiles. It is
+// NaN if p is not estimated or no value has been appended.
+func (s *PSquareMulti) Quantile(p float64) float64 {
+	i := sort.SearchFloat64s(s.ps, p)
+	if i == len(s.ps) || s.ps[i] != p {
+		return math.NaN()
+	}
+	return s.markers.quantile(i)
+}
+
+// Values appends the current estimates of all the quantiles, in the order of Quantiles, to dst and returns it.
+func (s *PSquareMulti) Values(dst []float64) []float64 {
+	for i := range s.ps {
+		dst = append(dst, s.markers.quantile(i))
+	}
+	return dst
+}
+
+// pSquareMarkers is the P² engine shared by PSquare and PSquareMulti.
+type pSquareMarkers struct {
+	// heights holds the first values until there is one per marker, then the marker heights.
+	heights []float64
+	// positions & desired are the actual and desired positions of the markers, from 1 to count.
+	positions []float64
+	desired   []float64
+	// increments are the increments of the desired positions, which are the fractions of the values below
+	// the markers.
+	increments []float64
+	// scratch holds the sorted first values.
+	scratch []float64
+	count   int
+}
+
+// init places the markers for the sorted quantiles ps: 0, p1/2, p1, (p1+p2)/2, p2, ..., pm, (pm+1)/2, 1.
+func (m *pSquareMarkers) init(ps []float64) {
+	m.increments = append(m.increments[:0], 0)
+	prev := 0.0
+	for _, p := range ps {
+		m.increments = append(m.increments, (prev+p)/2, p)
+		prev = p
+	}
+	m.increments = append(m.increments, (prev+1)/2, 1)
+
+	n := len(m.increments)
+	m.heights = m.heights[:0]
+	m.positions = resizeFloat64s(m.positions, n)
+	m.desired = resizeFloat64s(m.desired, n)
+	m.count = 0
+}
+
+func resizeFloat64s(xs []float64, n int) []float64 {
+	if cap(xs) < n {
+		return make([]float64, n)
+	}
+	return xs[:n]
+}
+
+func (m *pSquareMarkers) append(value float64) {
+	m.count++
+	n := len(m.increments)
+	if m.count <= n {
+		m.heights = append(m.heights, value)
+		if m.count == n {
+			sort.Float64s(m.heights)
+			for i := range m.positions {
+				m.positions[i] = float64(i + 1)
+				m.desired[i] = 1 + float64(n-1)*m.increments[i]
+			}
+		}
+		return
+	}
+
+	q := m.heights
+	// Find the cell k of the value, such as q[k] <= value < q[k+1], extending the extreme markers if needed.
+	var k int
+	switch {
+	case value < q[0]:
+		q[0] = value
+		k = 0
+	case value >= q[n-1]:
+		q[n-1] = value
+		k = n - 2
+	default:
+		k = sort.Search(n, func(i int) bool { return q[i] > value }) - 1
+	}
+	for i := k + 1; i < n; i++ {
+		m.positions[i]++
+	}
+	for i := range m.desired {
+		m.desired[i] = m.desired[i] + m.increments[i]
+	}
+
+	// Move the middle markers that are off their desired position by one or more.
+	pos := m.positions
+	for i := 1; i < n-1; i++ {
+		d := m.desired[i] - pos[i]
+		if (d >= 1 && pos[i+1]-pos[i] > 1) || (d <= -1 && pos[i-1]-pos[i] < -1) {
+			sign := 1.0
+			if d < 0 {
+				sign = -1
+			}
+			height := m.parabolic(i, sign)
+			if !(q[i-1] < height && height < q[i+1]) {
+				height = m.linear(i, sign)
+			}
+			q[i] = height
+			pos[i] = pos[i] + sign
+		}
+	}
+}
+
+// parabolic returns the height of marker i moved by d with the piecewise-parabolic (P²) formula.
+func (m *pSquareMarkers) parabolic(i int, d float64) float64 {
+	q, n := m.heights, m.positions
+	return q[i] + d/(n[i+1]-n[i-1])*
+		((n[i]-n[i-1]+d)*(q[i+1]-q[i])/(n[i+1]-n[i])+(n[i+1]-n[i]-d)*(q[i]-q[i-1])/(n[i]-n[i-1]))
+}
+
+// linear returns the height of marker i moved by d, interpolating linearly with its neighbour.
+func (m *pSquareMarkers) linear(i int, d float64) float64 {
+	q, n := m.heights, m.positions
+	j := i + int(d)
+	return q[i] + d*(q[j]-q[i])/(n[j]-n[i])
+}
+
+// quantile returns the estimate of the j-th quantile. Until all the markers are placed, the quantile is
+// interpolated between the sorted values.
+func (m *pSquareMarkers) quantile(j int) float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	marker := 2*j + 2
+	if m.count >= len(m.increments) {
+		return m.heights[marker]
+	}
+
+	m.scratch = append(m.scratch[:0], m.heights...)
+	sort.Float64s(m.scratch)
+	rank := m.increments[marker] * float64(m.count-1)
+	i := int(rank)
+	if i == m.count-1 {
+		return m.scratch[i]
+	}
+	return m.scratch[i] + (rank-float64(i))*(m.scratch[i+1]-m.scratch[i])
+}
@@ -0,0 +1,96 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestPSquare(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 100000)
+	for i := range values {
+		values[i] = r.NormFloat64()
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	exact := func(q float64) float64 {
+		return sorted[int(q*float64(len(sorted)-1))]
+	}
+
+	t.Run("First values", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewPSquare(0.5)
+		g.Expect(math.IsNaN(s.Value())).To(BeTrue())
+		s.Append(3)
+		g.Expect(s.Value()).To(Equal(3.0))
+		s.AppendMany([]float64{1, 4, 2})
+		g.Expect(s.Len()).To(Equal(4))
+		g.Expect(s.Value()).To(Equal(2.5))
+		s.Append(5)
+		g.Expect(s.Value()).To(Equal(3.0))
+		s.CleanPool()
+	})
+
+	t.Run("Jain & Chlamtac example", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewPSquare(0.5)
+		s.AppendMany([]float64{0.02, 0.15, 0.74, 3.39, 0.83, 22.37, 10.15, 15.43, 38.62, 15.92, 34.60, 10.28, 1.47,
+			0.40, 0.05, 11.39, 0.27, 0.42, 0.09, 11.37})
+		g.Expect(s.Value()).To(BeNumerically("~", 4.44, 0.01))
+		g.Expect(s.P()).To(Equal(0.5))
+		s.CleanPool()
+	})
+
+	t.Run("Normal distribution", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, p := range []float64{0.01, 0.1, 0.5, 0.9, 0.99} {
+			s := NewPSquare(p)
+			s.AppendMany(values)
+			g.Expect(s.Value()).To(BeNumerically("~", exact(p), 0.02), "p=%v", p)
+			s.CleanPool()
+		}
+	})
+
+	t.Run("Multiple quantiles", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewPSquareMulti(0.99, 0.5, 0.9, 0.1, 0.01)
+		g.Expect(s.Quantiles()).To(Equal([]float64{0.01, 0.1, 0.5, 0.9, 0.99}))
+		g.Expect(s.markers.heights).To(BeEmpty())
+		s.AppendMany(values)
+		g.Expect(s.Len()).To(Equal(len(values)))
+		for _, p := range s.Quantiles() {
+			g.Expect(s.Quantile(p)).To(BeNumerically("~", exact(p), 0.02), "p=%v", p)
+		}
+		g.Expect(math.IsNaN(s.Quantile(0.25))).To(BeTrue())
+		g.Expect(s.Values(nil)).To(HaveLen(5))
+		s.CleanPool()
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(func() { NewPSquare(0) }).To(Panic())
+		g.Expect(func() { NewPSquare(1) }).To(Panic())
+		g.Expect(func() { NewPSquareMulti() }).To(Panic())
+		g.Expect(func() { NewPSquareMulti(0.5, 0.5) }).To(Panic())
+		g.Expect(func() { NewPSquareMulti(0.5, 1.5) }).To(Panic())
+	})
+}
+
+func BenchmarkPSquare(b *testing.B) {
+	points := make([]float64, 300)
+	for i := range points {
+		points[i] = float64(i % 37)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := NewPSquare(0.99)
+		s.AppendMany(points)
+		s.Value()
+		s.CleanPool()
+	}
+}
@@ -0,0 +1,238 @@
+package gostats
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReservoirAlgorithm is the algorithm used by a Reservoir to pick its values.
+type ReservoirAlgorithm int
+
+const (
+	// ReservoirR is Vitter's Algorithm R: each value draws a random number, O(n) in total.
+	ReservoirR ReservoirAlgorithm = iota + 1
+	// ReservoirL is Li's Algorithm L: the number of values to skip is drawn instead, O(k(1 + log(n/k))) random
+	// numbers in total, which is faster on long streams.
+	ReservoirL
+	// ReservoirWeighted is the A-Res algorithm of Efraimidis & Spirakis: the probability of a value to be kept
+	// is proportional to its weight, see Reservoir.AppendWeighted.
+	ReservoirWeighted
+)
+
+var ReservoirPool = sync.Pool{
+	New: func() interface{} { return new(Reservoir) },
+}
+
+// Reservoir keeps a uniform random sample of at most size values of a stream, without knowing its length in
+// advance: after n values, each of them has the same probability size / n to be in the reservoir, or a
+// probability depending on its weight with ReservoirWeighted.
+type Reservoir struct {
+	algorithm ReservoirAlgorithm
+	size      int
+	rng       *rand.Rand
+	// values, positions (arrival indexes) and keys (for ReservoirWeighted) of the kept values
+	values    []float64
+	positions []int
+	keys      []float64
+	count     int
+	// Algorithm L
+	_w    float64
+	_next int
+}
+
+// NewReservoir returns an empty Reservoir of the given size, seeded from the current time. It panics if
+// size < 1 or the algorithm is unknown.
+func NewReservoir(size int, algorithm ReservoirAlgorithm) *Reservoir {
+	return NewReservoirWithSeed(size, algorithm, time.Now().UnixNano())
+}
+
+// NewReservoirWithSeed is like NewReservoir, with a seed making the sample deterministic.
+func NewReservoirWithSeed(size int, algorithm ReservoirAlgorithm, seed int64) *Reservoir {
+	if size < 1 {
+		panic("Reservoir size must be positive")
+	}
+	if algorithm < ReservoirR || algorithm > ReservoirWeighted {
+		panic("unknown reservoir algorithm")
+	}
+	r := ReservoirPool.Get().(*Reservoir)
+	r.initEmptyValues(size, algorithm, seed)
+	return r
+}
+
+func (r *Reservoir) CleanPool() {
+	ReservoirPool.Put(r)
+}
+
+func (r *Reservoir) initEmptyValues(size int, algorithm ReservoirAlgorithm, seed int64) {
+	r.algorithm = algorithm
+	r.size = size
+	if r.rng == nil {
+		r.rng = rand.New(rand.NewSource(seed))
+	} else {
+		r.rng.Seed(seed)
+	}
+	r.values = r.values[:0]
+	r.positions = r.positions[:0]
+	r.keys = r.keys[:0]
+	r.count = 0
+	r._w = 0
+	r._next = 0
+}
+
+// uniform returns a random number in (0, 1].
+func (r *Reservoir) uniform() float64 {
+	return 1 - r.rng.Float64()
+}
+
+// Append offers a value to the reservoir, with a weight of 1 for ReservoirWeighted.
+func (r *Reservoir) Append(value float64) {
+	r.AppendWeighted(value, 1)
+}
+
+func (r *Reservoir) AppendMany(values []float64) {
+	for _, value := range values {
+		r.AppendWeighted(value, 1)
+	}
+}
+
+// AppendWeighted offers a value with a weight to the reservoir. The weight is only used by ReservoirWeighted,
+// values with a weight <= 0 are never kept.
+func (r *Reservoir) AppendWeighted(value, weight float64) {
+	position := r.count
+	r.count++
+
+	if r.algorithm == ReservoirWeighted {
+		r.appendWeighted(value, weight, position)
+		return
+	}
+
+	if len(r.values) < r.size {
+		r.values = append(r.values, value)
+		r.positions = append(r.positions, position)
+		if len(r.values) == r.size && r.algorithm == ReservoirL {
+			r._w = math.Exp(math.Log(r.uniform()) / float64(r.size))
+			r.skip()
+		}
+		return
+	}
+
+	switch r.algorithm {
+	case ReservoirR:
+		if j := r.rng.Intn(r.count); j < r.size {
+			r.values[j] = value
+			r.positions[j] = position
+		}
+	case ReservoirL:
+		if position == r._next {
+			j := r.rng.Intn(r.size)
+			r.values[j] = value
+			r.positions[j] = position
+			r._w = r._w * math.Exp(math.Log(r.uniform())/float64(r.size))
+			r.skip()
+		}
+	}
+}
+
+// skip draws the position of the next value to keep with Algorithm L.
+func (r *Reservoir) skip() {
+	r._next = r.count + int(math.Floor(math.Log(r.uniform())/math.Log(1-r._w)))
+}
+
+// appendWeighted keeps the values with the largest keys u^(1/weight), u being uniform in (0, 1], in a min-heap.
+// The keys are compared as log(u) / weight, which does not underflow with large weights.
+func (r *Reservoir) appendWeighted(value, weight float64, position int) {
+	if !(weight > 0) {
+		return
+	}
+	key := math.Log(r.uniform()) / weight
+	if len(r.values) < r.size {
+		heap.Push((*reservoirHeap)(r), reservoirItem{value, position, key})
+		return
+	}
+	if key > r.keys[0] {
+		r.values[0], r.positions[0], r.keys[0] = value, position, key
+		heap.Fix((*reservoirHeap)(r), 0)
+	}
+}
+
+// Len returns the number of values in the reservoir.
+func (r *Reservoir) Len() int {
+	return len(r.values)
+}
+
+// Size returns the maximum number of values in the reservoir.
+func (r *Reservoir) Size() int {
+	return r.size
+}
+
+// Count returns the number of values offered to the reservoir.
+func (r *Reservoir) Count() int {
+	return r.count
+}
+
+// Values appends the values of the reservoir, in their arrival order, to dst and returns it.
+func (r *Reservoir) Values(dst []float64) []float64 {
+	for _, i := range r.arrivalOrder() {
+		dst = append(dst, r.values[i])
+	}
+	return dst
+}
+
+// Positions appends the arrival indexes of the values of the reservoir, in increasing order, to dst and returns
+// it.
+func (r *Reservoir) Positions(dst []int) []int {
+	for _, i := range r.arrivalOrder() {
+		dst = append(dst, r.positions[i])
+	}
+	return dst
+}
+
+func (r *Reservoir) arrivalOrder() []int {
+	order := make([]int, len(r.values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return r.positions[order[i]] < r.positions[order[j]] })
+	return order
+}
+
+// Sample returns a new Sample of the values of the reservoir, in their arrival order.
+func (r *Reservoir) Sample(withOriginal bool) *Sample {
+	return NewSampleWithValue(r.Values(make([]float64, 0, len(r.values))), withOriginal)
+}
+
+type reservoirItem struct {
+	value    float64
+	position int
+	key      float64
+}
+
+// reservoirHeap is the min-heap of the keys of a weighted Reservoir.
+type reservoirHeap Reservoir
+
+func (h *reservoirHeap) Len() int           { return len(h.keys) }
+func (h *reservoirHeap) Less(i, j int) bool { return h.keys[i] < h.keys[j] }
+
+func (h *reservoirHeap) Swap(i, j int) {
+	h.values[i], h.values[j] = h.values[j], h.values[i]
+	h.positions[i], h.positions[j] = h.positions[j], h.positions[i]
+	h.keys[i], h.keys[j] = h.keys[j], h.keys[i]
+}
+
+func (h *reservoirHeap) Push(x interface{}) {
+	item := x.(reservoirItem)
+	h.values = append(h.values, item.value)
+	h.positions = append(h.positions, item.position)
+	h.keys = append(h.keys, item.key)
+}
+
+func (h *reservoirHeap) Pop() interface{} {
+	n := len(h.keys) - 1
+	item := reservoirItem{h.values[n], h.positions[n], h.keys[n]}
+	h.values, h.positions, h.keys = h.values[:n], h.positions[:n], h.keys[:n]
+	return item
+}
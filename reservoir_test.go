@@ -0,0 +1,122 @@
+package gostats
+
+import (
+	"github.com/a-lucas/go-stats/stats"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestReservoir(t *testing.T) {
+
+	algorithms := map[string]ReservoirAlgorithm{
+		"Algorithm R": ReservoirR,
+		"Algorithm L": ReservoirL,
+		"A-Res":       ReservoirWeighted,
+	}
+
+	for name, algorithm := range algorithms {
+		algorithm := algorithm
+		t.Run(name, func(t *testing.T) {
+
+			t.Run("Short stream", func(t *testing.T) {
+				g := NewGomegaWithT(t)
+				r := NewReservoirWithSeed(10, algorithm, 1)
+				r.AppendMany([]float64{3, 1, 2})
+				g.Expect(r.Len()).To(Equal(3))
+				g.Expect(r.Size()).To(Equal(10))
+				g.Expect(r.Values(nil)).To(Equal([]float64{3, 1, 2}))
+				g.Expect(r.Positions(nil)).To(Equal([]int{0, 1, 2}))
+				r.CleanPool()
+			})
+
+			t.Run("Deterministic seed", func(t *testing.T) {
+				g := NewGomegaWithT(t)
+				r1 := NewReservoirWithSeed(10, algorithm, 42)
+				r2 := NewReservoirWithSeed(10, algorithm, 42)
+				for i := 0; i < 10000; i++ {
+					r1.Append(float64(i))
+					r2.Append(float64(i))
+				}
+				g.Expect(r1.Count()).To(Equal(10000))
+				g.Expect(r1.Len()).To(Equal(10))
+				g.Expect(r1.Values(nil)).To(Equal(r2.Values(nil)))
+				positions := r1.Positions(nil)
+				for i, value := range r1.Values(nil) {
+					g.Expect(value).To(Equal(float64(positions[i])))
+				}
+				r1.CleanPool()
+				r2.CleanPool()
+			})
+
+			t.Run("Uniformity", func(t *testing.T) {
+				g := NewGomegaWithT(t)
+				const trials, n, size = 4000, 100, 10
+				kept := make([]int, n)
+				r := NewReservoirWithSeed(size, algorithm, 0)
+				for trial := 0; trial < trials; trial++ {
+					r.initEmptyValues(size, algorithm, int64(trial))
+					for i := 0; i < n; i++ {
+						r.Append(float64(i))
+					}
+					for _, position := range r.Positions(nil) {
+						kept[position]++
+					}
+				}
+				// each value is kept with probability 0.1: 400 ± 19 times
+				for i := range kept {
+					g.Expect(kept[i]).To(BeNumerically("~", trials*size/n, 100), "position %d", i)
+				}
+				r.CleanPool()
+			})
+		})
+	}
+
+	t.Run("Weights", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		heavy, zero := 0, 0
+		r := NewReservoirWithSeed(1, ReservoirWeighted, 0)
+		for trial := 0; trial < 3000; trial++ {
+			r.initEmptyValues(1, ReservoirWeighted, int64(trial))
+			r.AppendWeighted(1, 1)
+			r.AppendWeighted(2, 2)
+			r.AppendWeighted(3, 0)
+			r.AppendWeighted(4, -1)
+			switch r.Values(nil)[0] {
+			case 2:
+				heavy++
+			case 3, 4:
+				zero++
+			}
+		}
+		g.Expect(heavy).To(BeNumerically("~", 2000, 100))
+		g.Expect(zero).To(Equal(0))
+		r.CleanPool()
+	})
+
+	t.Run("Sample", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r1 := NewReservoirWithSeed(200, ReservoirL, 1)
+		r2 := NewReservoirWithSeed(200, ReservoirL, 2)
+		for i := 0; i < 100000; i++ {
+			r1.Append(float64(i % 1000))
+			r2.Append(float64(i%1000 + 500))
+		}
+		s := r1.Sample(true)
+		g.Expect(s.Len()).To(Equal(200))
+		g.Expect(s.Original()).To(Equal(r1.Values(nil)))
+		g.Expect(s.Mean()).To(BeNumerically("~", 500, 60))
+
+		res, err := stats.MannWhitneyUTest(r1.Values(nil), r2.Values(nil), stats.LocationDiffers)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(res.P).To(BeNumerically("<", 0.001))
+		r1.CleanPool()
+		r2.CleanPool()
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(func() { NewReservoir(0, ReservoirR) }).To(Panic())
+		g.Expect(func() { NewReservoir(10, ReservoirAlgorithm(0)) }).To(Panic())
+		g.Expect(func() { NewReservoir(10, ReservoirWeighted+1) }).To(Panic())
+	})
+}
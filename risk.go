@@ -0,0 +1,191 @@
+package gostats
+
+import (
+	"math"
+
+	"github.com/a-lucas/go-stats/stats"
+)
+
+// RiskMethod is the way a RiskEstimate is computed.
+type RiskMethod string
+
+// RiskHistorical takes the quantiles of the values themselves.
+const RiskHistorical RiskMethod = "Historical"
+
+// RiskGaussian assumes the values are normally distributed, with their mean and standard deviation.
+const RiskGaussian RiskMethod = "Gaussian"
+
+// RiskCornishFisher adjusts the Gaussian quantiles with the skewness and the excess kurtosis of the values.
+const RiskCornishFisher RiskMethod = "CornishFisher"
+
+func (m RiskMethod) String() string {
+	return "Risk" + string(m)
+}
+
+// expectedShortfallSteps is the number of quantiles averaged by RiskFromQuantiles to estimate the expected
+// shortfall.
+const expectedShortfallSteps = 100
+
+// RiskEstimate is the Value-at-Risk and the Expected Shortfall of a series of returns or profits & losses at
+// a confidence level. Both are losses, positive when the tail of the values is negative.
+type RiskEstimate struct {
+	// Method is the way the estimate has been computed.
+	Method RiskMethod
+	// Confidence is the confidence level, such as 0.95 or 0.99.
+	Confidence float64
+	// VaR is the loss that is not exceeded with a probability Confidence: the opposite of the quantile
+	// 1 - Confidence of the values.
+	VaR float64
+	// ExpectedShortfall, or CVaR, is the mean loss when the loss is at least VaR.
+	ExpectedShortfall float64
+}
+
+func nanRiskEstimate(method RiskMethod, confidence float64) *RiskEstimate {
+	return &RiskEstimate{Method: method, Confidence: confidence, VaR: math.NaN(), ExpectedShortfall: math.NaN()}
+}
+
+// validateRisk checks the confidence and the method of a risk estimate.
+func validateRisk(confidence float64, method RiskMethod) error {
+	if !(confidence > 0 && confidence < 1) {
+		return ErrInvalidConfidence
+	}
+	switch method {
+	case RiskHistorical, RiskGaussian, RiskCornishFisher:
+		return nil
+	}
+	return ErrUnknownRiskMethod
+}
+
+// ValueAtRisk returns the Value-at-Risk and the Expected Shortfall of the Sample at the given confidence.
+// The Cornish-Fisher estimate is NaN when the Sample is constant.
+//
+// It panics if the confidence is not in (0, 1) or the method is unknown, see ValueAtRiskE. If the Sample is
+// empty, the estimate is NaN.
+func (s *Sample) ValueAtRisk(confidence float64, method RiskMethod) *RiskEstimate {
+	estimate, err := s.ValueAtRiskE(confidence, method)
+	switch err {
+	case nil:
+		return estimate
+	case ErrSampleSize:
+		return nanRiskEstimate(method, confidence)
+	}
+	panic(err)
+}
+
+// ValueAtRiskE is like ValueAtRisk, but returns an error instead of panicking.
+func (s *Sample) ValueAtRiskE(confidence float64, method RiskMethod) (*RiskEstimate, error) {
+	if err := validateRisk(confidence, method); err != nil {
+		return nil, err
+	}
+	if s.nb == 0 {
+		return nil, ErrSampleSize
+	}
+	if method != RiskHistorical {
+		return parametricRisk(method, confidence, s.Mean(), s.StdDev(), s.Skewness(), s.Kurtosis()), nil
+	}
+
+	quantile := s.Percentile(1 - confidence)
+	// Percentile sorted the values.
+	sum, n := 0.0, 0
+	for _, value := range s.xs[:s.nb] {
+		if value > quantile {
+			break
+		}
+		sum += value
+		n++
+	}
+	return &RiskEstimate{
+		Method:            RiskHistorical,
+		Confidence:        confidence,
+		VaR:               -quantile,
+		ExpectedShortfall: -sum / float64(n),
+	}, nil
+}
+
+// ValueAtRisk returns the Value-at-Risk and the Expected Shortfall of the stream at the given confidence.
+// RiskHistorical needs a stream created with NewSampleStreamWithQuantiles, and is NaN otherwise, see
+// RiskFromQuantiles. It panics on misuse, like Sample.ValueAtRisk. Like Quantile, it must not be called
+// concurrently with any other method of the stream.
+func (s *SampleStream) ValueAtRisk(confidence float64, method RiskMethod) *RiskEstimate {
+	estimate, err := s.ValueAtRiskE(confidence, method)
+	switch err {
+	case nil:
+		return estimate
+	case ErrSampleSize:
+		return nanRiskEstimate(method, confidence)
+	}
+	panic(err)
+}
+
+// ValueAtRiskE is like ValueAtRisk, but returns an error instead of panicking.
+func (s *SampleStream) ValueAtRiskE(confidence float64, method RiskMethod) (*RiskEstimate, error) {
+	if err := validateRisk(confidence, method); err != nil {
+		return nil, err
+	}
+	if s.Len() == 0 {
+		return nil, ErrSampleSize
+	}
+	if method != RiskHistorical {
+		return parametricRisk(method, confidence, s.Mean(), s.StdDev(), s.Skewness(), s.Kurtosis()), nil
+	}
+	if s.digest == nil {
+		return nanRiskEstimate(method, confidence), nil
+	}
+	return RiskFromQuantiles(s.digest, confidence), nil
+}
+
+// QuantileEstimator estimates the quantiles of a distribution, such as a TDigest, a DDSketch or a SampleStream
+// created with NewSampleStreamWithQuantiles.
+type QuantileEstimator interface {
+	// Quantile returns the value below which a fraction q of the values fall.
+	Quantile(q float64) float64
+}
+
+// RiskFromQuantiles returns the historical Value-at-Risk and Expected Shortfall of the values summarized by a
+// quantile sketch, which allows to check risk limits on a live stream without keeping its values. The Expected
+// Shortfall is the mean of the quantiles of the tail at evenly spaced ranks. A TDigest, or a SampleStream,
+// estimator is modified by its Quantile method, so it must not be used concurrently meanwhile.
+//
+// It panics if the confidence is not in (0, 1).
+func RiskFromQuantiles(estimator QuantileEstimator, confidence float64) *RiskEstimate {
+	if err := validateRisk(confidence, RiskHistorical); err != nil {
+		panic(err)
+	}
+	alpha := 1 - confidence
+	sum := 0.0
+	for i := 0; i < expectedShortfallSteps; i++ {
+		sum += estimator.Quantile((float64(i) + 0.5) * alpha / expectedShortfallSteps)
+	}
+	return &RiskEstimate{
+		Method:            RiskHistorical,
+		Confidence:        confidence,
+		VaR:               -estimator.Quantile(alpha),
+		ExpectedShortfall: -sum / expectedShortfallSteps,
+	}
+}
+
+// parametricRisk computes the Gaussian or Cornish-Fisher estimate from the moments of the values, skewness and
+// kurtosis being g1 and the excess g2.
+//
+// With z the standard normal quantile at alpha = 1 - confidence, the Cornish-Fisher quantile is
+// z + (z² - 1) g1 / 6 + (z³ - 3z) g2 / 24 - (2z³ - 5z) g1² / 36, and its mean over the tail, integrated against
+// the normal density φ, is -φ(z) (1 + z g1 / 6 + (z² - 1) g2 / 24 + (1 - 2z²) g1² / 36) / alpha, which is the
+// Gaussian -φ(z) / alpha when g1 = g2 = 0.
+func parametricRisk(method RiskMethod, confidence, mean, stdDev, skew, kurt float64) *RiskEstimate {
+	alpha := 1 - confidence
+	z := stats.StdNormal.InvCDF(alpha)
+	pdf := stats.StdNormal.PDF(z)
+
+	quantile, tail := z, -pdf/alpha
+	if method == RiskCornishFisher {
+		z2 := z * z
+		quantile = z + (z2-1)*skew/6 + (z2-3)*z*kurt/24 - (2*z2-5)*z*skew*skew/36
+		tail = -pdf * (1 + z*skew/6 + (z2-1)*kurt/24 + (1-2*z2)*skew*skew/36) / alpha
+	}
+	return &RiskEstimate{
+		Method:            method,
+		Confidence:        confidence,
+		VaR:               -(mean + stdDev*quantile),
+		ExpectedShortfall: -(mean + stdDev*tail),
+	}
+}
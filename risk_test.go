@@ -0,0 +1,130 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/a-lucas/go-stats/stats"
+)
+
+func TestValueAtRisk(t *testing.T) {
+
+	t.Run("Historical", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		values := make([]float64, 100)
+		for i := range values {
+			values[i] = float64(99 - i - 50)
+		}
+		r := NewSampleWithValue(values, false).ValueAtRisk(0.95, RiskHistorical)
+		g.Expect(r.Method).To(Equal(RiskHistorical))
+		g.Expect(r.Confidence).To(Equal(0.95))
+		g.Expect(r.VaR).To(Equal(45.5))
+		g.Expect(r.ExpectedShortfall).To(Equal(48.0))
+	})
+
+	t.Run("Gaussian", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue([]float64{0.01, -0.02, 0.03, 0.005, -0.01, 0.02}, false)
+		m, sd := s.Mean(), s.StdDev()
+		r := s.ValueAtRisk(0.95, RiskGaussian)
+		g.Expect(r.VaR).To(BeNumerically("~", -(m - 1.6448536269514722*sd), 1e-9))
+		g.Expect(r.ExpectedShortfall).To(BeNumerically("~", -(m - 2.0627128075074256*sd), 1e-9))
+	})
+
+	t.Run("Cornish-Fisher", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		rng := rand.New(rand.NewSource(3))
+		values := make([]float64, 2000)
+		for i := range values {
+			values[i] = math.Exp(0.5*rng.NormFloat64()) - 1.1
+		}
+		s := NewSampleWithValue(values, false)
+		m, sd, skew, kurt := s.Mean(), s.StdDev(), s.Skewness(), s.Kurtosis()
+		cf := func(p float64) float64 {
+			z := stats.StdNormal.InvCDF(p)
+			return m + sd*(z+(z*z-1)*skew/6+(z*z*z-3*z)*kurt/24-(2*z*z*z-5*z)*skew*skew/36)
+		}
+
+		for _, confidence := range []float64{0.9, 0.99} {
+			r := s.ValueAtRisk(confidence, RiskCornishFisher)
+			g.Expect(r.VaR).To(BeNumerically("~", -cf(1-confidence), 1e-12))
+			// The tail mean, integrated numerically.
+			alpha, steps, sum := 1-confidence, 100000, 0.0
+			for i := 0; i < steps; i++ {
+				sum += cf((float64(i) + 0.5) * alpha / float64(steps))
+			}
+			g.Expect(r.ExpectedShortfall).To(BeNumerically("~", -sum/float64(steps), 1e-4))
+			g.Expect(r.ExpectedShortfall).To(BeNumerically(">", r.VaR))
+		}
+
+		// Positive skewness makes the left tail thinner than the Gaussian one.
+		g.Expect(skew).To(BeNumerically(">", 0))
+		g.Expect(s.ValueAtRisk(0.99, RiskCornishFisher).VaR).To(BeNumerically("<", s.ValueAtRisk(0.99, RiskGaussian).VaR))
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		rng := rand.New(rand.NewSource(5))
+		s := NewSampleFromPool(false)
+		stream := NewSampleStreamWithQuantiles(100)
+		sketch := NewDDSketch(0.01, DefaultDDSketchMaxBuckets)
+		for i := 0; i < 20000; i++ {
+			value := 0.01*rng.NormFloat64() + 0.001
+			s.Append(value)
+			stream.Append(value)
+			sketch.Append(value)
+		}
+
+		exact := s.ValueAtRisk(0.99, RiskHistorical)
+		for _, r := range []*RiskEstimate{
+			stream.ValueAtRisk(0.99, RiskHistorical),
+			RiskFromQuantiles(stream.Digest(), 0.99),
+			RiskFromQuantiles(sketch, 0.99),
+		} {
+			g.Expect(r.VaR).To(BeNumerically("~", exact.VaR, exact.VaR*0.03))
+			g.Expect(r.ExpectedShortfall).To(BeNumerically("~", exact.ExpectedShortfall, exact.ExpectedShortfall*0.03))
+		}
+
+		for _, method := range []RiskMethod{RiskGaussian, RiskCornishFisher} {
+			r := stream.ValueAtRisk(0.99, method)
+			expected := s.ValueAtRisk(0.99, method)
+			g.Expect(r.VaR).To(BeNumerically("~", expected.VaR, 1e-9))
+			g.Expect(r.ExpectedShortfall).To(BeNumerically("~", expected.ExpectedShortfall, 1e-9))
+		}
+
+		// A risk limit checked on live P&L.
+		limit := 0.03
+		g.Expect(RiskFromQuantiles(sketch, 0.99).VaR).To(BeNumerically("<", limit))
+		for i := 0; i < 2000; i++ {
+			sketch.Append(-0.1)
+		}
+		g.Expect(RiskFromQuantiles(sketch, 0.99).VaR).To(BeNumerically(">", limit))
+
+		plain := NewSampleStream()
+		plain.Append(1)
+		g.Expect(math.IsNaN(plain.ValueAtRisk(0.99, RiskHistorical).VaR)).To(BeTrue())
+		plain.CleanPool()
+		stream.CleanPool()
+		s.BackToPool()
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue([]float64{1, 2}, false)
+		_, err := s.ValueAtRiskE(1, RiskHistorical)
+		g.Expect(err).To(Equal(ErrInvalidConfidence))
+		_, err = s.ValueAtRiskE(0.95, RiskMethod("Monte-Carlo"))
+		g.Expect(err).To(Equal(ErrUnknownRiskMethod))
+		g.Expect(func() { s.ValueAtRisk(0, RiskGaussian) }).To(Panic())
+		g.Expect(func() { RiskFromQuantiles(NewTDigest(100), 1.5) }).To(Panic())
+
+		r := NewSampleWithValue(nil, false).ValueAtRisk(0.95, RiskGaussian)
+		g.Expect(r.Method).To(Equal(RiskGaussian))
+		g.Expect(math.IsNaN(r.VaR)).To(BeTrue())
+		_, err = NewSampleStream().ValueAtRiskE(0.95, RiskGaussian)
+		g.Expect(err).To(Equal(ErrSampleSize))
+		g.Expect(RiskCornishFisher.String()).To(Equal("RiskCornishFisher"))
+	})
+}
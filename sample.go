@@ -10,14 +10,6 @@ import (
 	"sync"
 )
 
-type DistanceType int
-
-const (
-	DistanceChebyshev DistanceType = 1
-	DistanceEuclidean DistanceType = 2
-	DistanceManhattan DistanceType = 3
-)
-
 var samplePool = sync.Pool{
 	New: func() interface{} {
 		return &Sample{
@@ -56,11 +48,15 @@ type Sample struct {
 	stdDev             float64
 	populationVariance float64
 	stdDevPopulation   float64
-	min                float64
-	max                float64
-	nbPositives        int16
-	nbProfitStreak     int16
-	nbLossStreak       int16
+	skewness           float64
+	kurtosis           float64
+	// momentsComputed tells whether skewness and kurtosis are cached, as they are NaN for a constant Sample
+	momentsComputed bool
+	min             float64
+	max             float64
+	nbPositives     int16
+	nbProfitStreak  int16
+	nbLossStreak    int16
 	// more than internal
 	_nbPositiveStreak int16
 	_nbNegativeStreak int16
@@ -76,6 +72,8 @@ func NewSampleWithValue(inputs []float64, withOriginal bool) *Sample {
 		stdDev:             math.NaN(),
 		populationVariance: math.NaN(),
 		stdDevPopulation:   math.NaN(),
+		skewness:           math.NaN(),
+		kurtosis:           math.NaN(),
 		withOriginal:       withOriginal,
 		sorted:             false,
 		min:                math.Inf(+1),
@@ -160,6 +158,9 @@ func (s *Sample) initEmptyValues(withOriginal bool) {
 	s.stdDev = math.NaN()
 	s.populationVariance = math.NaN()
 	s.stdDevPopulation = math.NaN()
+	s.skewness = math.NaN()
+	s.kurtosis = math.NaN()
+	s.momentsComputed = false
 	s.sorted = false
 	s.nbPositives = 0
 	s.min = math.Inf(+1)
@@ -201,20 +202,53 @@ func (s *Sample) walk(value float64) {
 	s.nb++
 }
 
-// Append a value to the sample.
+// Append a value to the sample. The statistics cached so far are computed again when next asked for.
 func (s *Sample) Append(value float64) {
 	s.xs = append(s.xs, value)
 	if s.withOriginal {
 		s.original = append(s.original, value)
 	}
 	s.walk(value)
+	s.invalidate()
 }
 
+// AppendMany appends values to the sample, like Append.
+func (s *Sample) AppendMany(values []float64) {
+	for _, value := range values {
+		s.Append(value)
+	}
+}
+
+// invalidate drops the statistics cached from the previous values.
+func (s *Sample) invalidate() {
+	s.mean = math.NaN()
+	s.geoMean = math.NaN()
+	s.variance = math.NaN()
+	s.stdDev = math.NaN()
+	s.populationVariance = math.NaN()
+	s.stdDevPopulation = math.NaN()
+	s.skewness = math.NaN()
+	s.kurtosis = math.NaN()
+	s.momentsComputed = false
+	s.sorted = false
+}
+
+// Original returns the values in the order they have been appended.
+// It panics if the Sample has been created with withOriginal == false.
 func (s *Sample) Original() []float64 {
+	original, err := s.OriginalE()
+	if err != nil {
+		panic(err)
+	}
+	return original
+}
+
+// OriginalE is like Original, but returns ErrNoOriginal instead of panicking.
+func (s *Sample) OriginalE() ([]float64, error) {
 	if !s.withOriginal {
-		panic("only works when withOriginal == true")
+		return nil, ErrNoOriginal
 	}
-	return s.original
+	return s.original, nil
 }
 
 // Bounds returns the minimum and maximum values of the Sample.
@@ -259,67 +293,40 @@ func (s *Sample) Len() int {
 	return s.nb
 }
 
-// Calculate the Distance ( Chebyshev - buggy / Euclidean / Manhattan ) with another Sample
-func (s *Sample) Distance(data *Sample, distanceType DistanceType) float64 {
-	if !s.validateAgainst(data) {
-		return math.NaN()
-	}
-	switch distanceType {
-	case DistanceChebyshev:
-		panic("Definitively buggy")
-		var tempDistance float64
-		distance := 0.0
-		for i := 0; i < s.nb; i++ {
-			tempDistance = math.Abs(s.Original()[i] - data.Original()[i])
-
-			if distance < tempDistance {
-				distance = tempDistance
-			}
-		}
-		return distance
-	case DistanceEuclidean:
-		distance := 0.0
-		for i := 0; i < s.nb; i++ {
-			distance = distance + math.Pow(s.Original()[i]-data.Original()[i], 2)
-		}
-		return distance / math.Pow(float64(s.nb), 2)
-	case DistanceManhattan:
-		distance := 0.0
-		for i := 0; i < s.nb; i++ {
-			distance = distance + math.Abs(s.Original()[i]-data.Original()[i])
-		}
-		return distance / float64(s.nb)
-	default:
-		panic("Unknown distance")
+// validatePair checks that s and data can be compared value by value,
+// in their original order.
+func (s *Sample) validatePair(data *Sample) error {
+	if !s.withOriginal || !data.withOriginal {
+		return ErrNoOriginal
 	}
+	return s.validateLengths(data)
 }
 
-/**
-returns true if valid
-*/
-func (s *Sample) validateAgainst(data *Sample) bool {
+// validateLengths checks that s and data have the same, non-zero, length.
+// Distance and the Pearson correlation check it before the original
+// values, so that they return NaN for such Samples, as they always have.
+func (s *Sample) validateLengths(data *Sample) error {
 	if s.nb == 0 || data.Len() == 0 {
-		return false
+		return ErrSampleSize
 	}
 	if s.nb != data.Len() {
-		return false
+		return ErrMismatchedSamples
 	}
-	return true
+	return nil
 }
 
 // CovariancePopulation computes covariance for entire population between two variables.
 // https://corporatefinanceinstitute.com/resources/knowledge/finance/covariance/
+// It panics if any of the Samples has no original values, see CovariancePopulationE.
 func (s *Sample) CovariancePopulation(data *Sample) float64 {
+	return nanOrPanic(s.CovariancePopulationE(data))
+}
 
-	if !s.withOriginal {
-		panic("CovariancePopulation needs original Access")
-	}
-	if !data.withOriginal {
-		panic("CovariancePopulation needs original Access")
-	}
-
-	if !s.validateAgainst(data) {
-		return math.NaN()
+// CovariancePopulationE is like CovariancePopulation, but returns an error
+// instead of panicking.
+func (s *Sample) CovariancePopulationE(data *Sample) (float64, error) {
+	if err := s.validatePair(data); err != nil {
+		return math.NaN(), err
 	}
 
 	l1 := data.Len()
@@ -333,38 +340,43 @@ func (s *Sample) CovariancePopulation(data *Sample) float64 {
 		delta2 := s.original[i] - m2
 		ss += delta1 * delta2
 	}
-	return ss / float64(l1)
+	return ss / float64(l1), nil
 }
 
-// Calculate the Pearson Correlation with another Sample
+// Calculate the Correlation ( Pearson / Spearman / Kendall ) with another Sample.
+// Any other correlationType must have been registered with RegisterCorrelation.
+// It panics on misuse, see CorrelationE.
 func (s *Sample) Correlation(data *Sample, correlationType CorrelationType) float64 {
-	switch correlationType {
-	case CorrelationPearson:
+	return nanOrPanic(s.CorrelationE(data, correlationType))
+}
+
+// CorrelationE is like Correlation, but returns an error instead of panicking.
+func (s *Sample) CorrelationE(data *Sample, correlationType CorrelationType) (float64, error) {
+	if correlationType == CorrelationPearson {
 		return s.correlationPearson(data)
-	default:
-		panic("Correlation not implemented")
 	}
+	res, err := s.CorrelationTestE(data, correlationType)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return res.Coefficient, nil
 }
 
 // Correlation describes the degree of relationship between two sets of data
-func (s *Sample) correlationPearson(data *Sample) float64 {
-	l1 := data.Len()
-	l2 := s.nb
-	if l1 == 0 || l2 == 0 {
-		return math.NaN()
-	}
-	if l1 != l2 {
-		return math.NaN()
+func (s *Sample) correlationPearson(data *Sample) (float64, error) {
+	if err := s.validateLengths(data); err != nil {
+		return math.NaN(), err
 	}
 
 	dev1 := data.StandardDeviationPopulation()
 	dev2 := s.StandardDeviationPopulation()
 
 	if dev1 == 0 || dev2 == 0 {
-		return 0
+		return 0, nil
 	}
 
-	return s.CovariancePopulation(data) / (dev1 * dev2)
+	cov, err := s.CovariancePopulationE(data)
+	return cov / (dev1 * dev2), err
 }
 
 // StandardDeviationPopulation finds the amount of variation from the population
@@ -427,6 +439,80 @@ func (s *Sample) StdDev() float64 {
 	return s.stdDev
 }
 
+// Skewness returns the sample skewness g1 = m3 / m2^(3/2) of the Sample, m2 and m3 being its second and
+// third central moments. It is NaN when the Sample is empty or constant.
+func (s *Sample) Skewness() float64 {
+	if !s.momentsComputed {
+		s.processMoments()
+	}
+	return s.skewness
+}
+
+// Kurtosis returns the sample excess kurtosis g2 = m4 / m2² - 3 of the Sample, m2 and m4 being its second
+// and fourth central moments. It is NaN when the Sample is empty or constant.
+func (s *Sample) Kurtosis() float64 {
+	if !s.momentsComputed {
+		s.processMoments()
+	}
+	return s.kurtosis
+}
+
+// SkewnessAdjusted returns the adjusted Fisher-Pearson skewness G1, which corrects the bias of Skewness on
+// samples from a normal distribution. It is NaN for less than 3 values.
+func (s *Sample) SkewnessAdjusted() float64 {
+	return skewnessAdjusted(float64(s.nb), s.Skewness())
+}
+
+// KurtosisAdjusted returns the excess kurtosis G2, which corrects the bias of Kurtosis on samples from a
+// normal distribution. It is NaN for less than 4 values.
+func (s *Sample) KurtosisAdjusted() float64 {
+	return kurtosisAdjusted(float64(s.nb), s.Kurtosis())
+}
+
+// processMoments computes the skewness and kurtosis in a single pass.
+func (s *Sample) processMoments() {
+	s.momentsComputed = true
+	if s.nb == 0 {
+		return
+	}
+	m := s.Mean()
+	m2, m3, m4 := 0.0, 0.0, 0.0
+	for i := 0; i < s.nb; i++ {
+		d := s.xs[i] - m
+		d2 := d * d
+		m2 += d2
+		m3 += d2 * d
+		m4 += d2 * d2
+	}
+	n := float64(s.nb)
+	s.skewness = skewness(n, m2, m3)
+	s.kurtosis = kurtosis(n, m2, m4)
+}
+
+// skewness returns g1 from the sums of the squared and cubed deviations of n values.
+func skewness(n, m2, m3 float64) float64 {
+	return math.Sqrt(n) * m3 / math.Pow(m2, 1.5)
+}
+
+// kurtosis returns g2 from the sums of the squared and fourth power deviations of n values.
+func kurtosis(n, m2, m4 float64) float64 {
+	return n*m4/(m2*m2) - 3
+}
+
+func skewnessAdjusted(n, g1 float64) float64 {
+	if n < 3 {
+		return math.NaN()
+	}
+	return g1 * math.Sqrt(n*(n-1)) / (n - 2)
+}
+
+func kurtosisAdjusted(n, g2 float64) float64 {
+	if n < 4 {
+		return math.NaN()
+	}
+	return ((n+1)*g2 + 6) * (n - 1) / ((n - 2) * (n - 3))
+}
+
 // Get the percentile - 0<value<1 is
 func (s *Sample) Percentile(value float64) float64 {
 	if s.nb == 0 {
@@ -2,6 +2,7 @@ package gostats
 
 import (
 	. "github.com/onsi/gomega"
+	"math/rand"
 	"testing"
 )
 
@@ -133,4 +134,27 @@ func TestMyStats3(t *testing.T) {
 		}
 	})
 
+	t.Run("Skewness/Kurtosis", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleStream()
+		s.AppendMany([]float64{2, 8, 0, 4, 1, 9, 9, 0})
+		g.Expect(s.Skewness()).To(BeNumerically("~", 0.2650554122698573, 1e-12))
+		g.Expect(s.Kurtosis()).To(BeNumerically("~", -1.6660010752838508, 1e-12))
+		g.Expect(s.SkewnessAdjusted()).To(BeNumerically("~", 0.33058218040797466, 1e-12))
+		g.Expect(s.KurtosisAdjusted()).To(BeNumerically("~", -2.098602258096087, 1e-12))
+		s.CleanPool()
+
+		r := rand.New(rand.NewSource(1))
+		values := make([]float64, 1000)
+		for i := range values {
+			values[i] = r.ExpFloat64()*100 + 1e4
+		}
+		s = NewSampleStream()
+		s.AppendMany(values)
+		sample := NewSampleWithValue(values, false)
+		g.Expect(s.Skewness()).To(BeNumerically("~", sample.Skewness(), 1e-9))
+		g.Expect(s.Kurtosis()).To(BeNumerically("~", sample.Kurtosis(), 1e-9))
+		s.CleanPool()
+	})
+
 }
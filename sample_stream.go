@@ -25,13 +25,22 @@ type SampleStream struct {
 	// more than internal
 	_nbPositiveStreak int16
 	_nbNegativeStreak int16
+	// length of the streaks starting with the first value, needed to Merge
+	_leadingPositiveStreak int16
+	_leadingNegativeStreak int16
 	// streaming
 	_initialized bool
 	_prevMean    float64
 	//_absPrevMean    float64
 	_prevVariance float64
 	//_absPreVariance float64
+	_m3    float64
+	_m4    float64
 	_count float64
+	// quantiles, when enabled
+	digest *TDigest
+	// trade statistics, when enabled
+	trades *TradeStats
 }
 
 func NewSampleStream() *SampleStream {
@@ -40,7 +49,53 @@ func NewSampleStream() *SampleStream {
 	return s
 }
 
+// NewSampleStreamWithQuantiles returns a SampleStream that also feeds a TDigest with the given compression,
+// so that Quantile and CDF can be answered.
+func NewSampleStreamWithQuantiles(compression float64) *SampleStream {
+	s := NewSampleStream()
+	s.digest = NewTDigest(compression)
+	return s
+}
+
+// NewSampleStreamWithTradeStats returns a SampleStream that also feeds a TradeStats, counting the zero values as
+// told by zero, so that the trade statistics of the appended profits & losses are available.
+func NewSampleStreamWithTradeStats(zero ZeroTrades) *SampleStream {
+	return NewSampleStreamWithOptions(SampleStreamOptions{TradeStats: true, ZeroTrades: zero})
+}
+
+// SampleStreamOptions configures the optional statistics of a SampleStream.
+type SampleStreamOptions struct {
+	// Quantiles is the compression of the TDigest answering Quantile and CDF, 0 meaning no quantiles, see
+	// NewSampleStreamWithQuantiles.
+	Quantiles float64
+	// TradeStats enables the trade statistics, see NewSampleStreamWithTradeStats.
+	TradeStats bool
+	// ZeroTrades is the way the trade statistics count the zero values.
+	ZeroTrades ZeroTrades
+}
+
+// NewSampleStreamWithOptions returns a SampleStream with any of the optional statistics. It panics if the
+// compression is not 0 and below 1, or if the zero handling is unknown.
+func NewSampleStreamWithOptions(options SampleStreamOptions) *SampleStream {
+	s := NewSampleStream()
+	if options.Quantiles != 0 {
+		s.digest = NewTDigest(options.Quantiles)
+	}
+	if options.TradeStats {
+		s.trades = NewTradeStats(options.ZeroTrades)
+	}
+	return s
+}
+
 func (s *SampleStream) CleanPool() {
+	if s.digest != nil {
+		s.digest.CleanPool()
+		s.digest = nil
+	}
+	if s.trades != nil {
+		s.trades.CleanPool()
+		s.trades = nil
+	}
 	SampleStreamPool.Put(s)
 }
 
@@ -51,12 +106,18 @@ func (s *SampleStream) initEmptyValues() {
 	s.sum = 0
 	s._nbNegativeStreak = 0
 	s._nbPositiveStreak = 0
+	s._leadingNegativeStreak = 0
+	s._leadingPositiveStreak = 0
 	s._initialized = false
 	s._prevVariance = 0
 	s._prevMean = 0
+	s._m3 = 0
+	s._m4 = 0
 	s._count = 0
 	s.nbProfitStreak = 0
 	s.nbLossStreak = 0
+	s.digest = nil
+	s.trades = nil
 }
 
 func (s *SampleStream) AppendMany(values []float64) {
@@ -67,6 +128,12 @@ func (s *SampleStream) AppendMany(values []float64) {
 
 func (s *SampleStream) Append(value float64) {
 
+	if s.digest != nil {
+		s.digest.Append(value)
+	}
+	if s.trades != nil {
+		s.trades.Append(value)
+	}
 	s.sum = s.sum + value
 	if value > 0 {
 		s.nbPositives++
@@ -75,12 +142,18 @@ func (s *SampleStream) Append(value float64) {
 		if s._nbPositiveStreak > s.nbProfitStreak {
 			s.nbProfitStreak = s._nbPositiveStreak
 		}
+		if float64(s._leadingPositiveStreak) == s._count {
+			s._leadingPositiveStreak++
+		}
 	} else {
 		s._nbNegativeStreak++
 		s._nbPositiveStreak = 0
 		if s._nbNegativeStreak > s.nbLossStreak {
 			s.nbLossStreak = s._nbNegativeStreak
 		}
+		if float64(s._leadingNegativeStreak) == s._count {
+			s._leadingNegativeStreak++
+		}
 	}
 
 	s._count = s._count + 1
@@ -95,6 +168,13 @@ func (s *SampleStream) Append(value float64) {
 		} else if value > s.max {
 			s.max = value
 		}
+		// third & fourth moments (Terriberry / Pébay), from the previous mean & variance
+		delta := value - s._prevMean
+		deltaN := delta / s._count
+		deltaN2 := deltaN * deltaN
+		term1 := delta * deltaN * (s._count - 1)
+		s._m4 = s._m4 + term1*deltaN2*(s._count*s._count-3*s._count+3) + 6*deltaN2*s._prevVariance - 4*deltaN*s._m3
+		s._m3 = s._m3 + term1*deltaN*(s._count-2) - 3*deltaN*s._prevVariance
 		// mean
 		_prevMean := s._prevMean + (value-s._prevMean)/s._count
 		s._prevVariance = s._prevVariance + (value-s._prevMean)*(value-_prevMean)
@@ -103,6 +183,11 @@ func (s *SampleStream) Append(value float64) {
 
 }
 
+// Len returns the number of appended values.
+func (s *SampleStream) Len() int {
+	return int(s._count)
+}
+
 // Bounds returns the minimum and maximum values of the Sample.
 //
 // If the Sample is weighted, this ignores samples with zero weight.
@@ -131,3 +216,54 @@ func (s *SampleStream) Variance() float64 {
 func (s *SampleStream) StdDev() float64 {
 	return math.Pow(s.Variance(), 0.5)
 }
+
+// Skewness returns the sample skewness g1 of the stream, see Sample.Skewness.
+func (s *SampleStream) Skewness() float64 {
+	return skewness(s._count, s._prevVariance, s._m3)
+}
+
+// Kurtosis returns the sample excess kurtosis g2 of the stream, see Sample.Kurtosis.
+func (s *SampleStream) Kurtosis() float64 {
+	return kurtosis(s._count, s._prevVariance, s._m4)
+}
+
+// SkewnessAdjusted returns the adjusted Fisher-Pearson skewness G1 of the stream, see Sample.SkewnessAdjusted.
+func (s *SampleStream) SkewnessAdjusted() float64 {
+	return skewnessAdjusted(s._count, s.Skewness())
+}
+
+// KurtosisAdjusted returns the adjusted excess kurtosis G2 of the stream, see Sample.KurtosisAdjusted.
+func (s *SampleStream) KurtosisAdjusted() float64 {
+	return kurtosisAdjusted(s._count, s.Kurtosis())
+}
+
+// Quantile returns the estimated value below which a fraction q of the values fall, see TDigest.Quantile.
+// It is NaN unless the stream has been created with NewSampleStreamWithQuantiles. As it compresses the
+// digest, it must not be called concurrently with any other method of the stream.
+func (s *SampleStream) Quantile(q float64) float64 {
+	if s.digest == nil {
+		return math.NaN()
+	}
+	return s.digest.Quantile(q)
+}
+
+// CDF returns the estimated fraction of the values that are less than or equal to x, see TDigest.CDF.
+// It is NaN unless the stream has been created with NewSampleStreamWithQuantiles. Like Quantile, it must not
+// be called concurrently with any other method of the stream.
+func (s *SampleStream) CDF(x float64) float64 {
+	if s.digest == nil {
+		return math.NaN()
+	}
+	return s.digest.CDF(x)
+}
+
+// Digest returns the TDigest fed by the stream, or nil.
+func (s *SampleStream) Digest() *TDigest {
+	return s.digest
+}
+
+// TradeStats returns the TradeStats fed by the stream, or nil unless the stream has been created with
+// NewSampleStreamWithTradeStats.
+func (s *SampleStream) TradeStats() *TradeStats {
+	return s.trades
+}
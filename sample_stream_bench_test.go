@@ -1,6 +1,7 @@
 package gostats
 
 import (
+	"fmt"
 	"github.com/a-lucas/go-stats/stats"
 	"sync"
 	"testing"
@@ -244,4 +245,74 @@ func BenchmarkSampleStream300Points(b *testing.B) {
 
 	})
 
+	b.Run("Shared Sample Stream Multiple Routine - process 1000 times the 300 points", func(b *testing.B) {
+
+		launchWithConcurrency := func(concurrency int, appendValue func(float64), read func()) {
+			ch := make(chan []float64, 30)
+			go func() {
+				for i := 0; i < 1000; i++ {
+					ch <- points
+				}
+				close(ch)
+			}()
+
+			var wg sync.WaitGroup
+			wg.Add(concurrency)
+			for i := 0; i < concurrency; i++ {
+				go func() {
+					for {
+						if values, more := <-ch; more {
+							for _, val := range values {
+								appendValue(val)
+							}
+							read()
+						} else {
+							wg.Done()
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		}
+
+		for _, concurrency := range []int{5, 10, 40, 100} {
+			concurrency := concurrency
+			b.Run(fmt.Sprintf("Concurrency %d", concurrency), func(b *testing.B) {
+				b.Run("Mutex", func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						var mu sync.Mutex
+						st := NewSampleStream()
+						launchWithConcurrency(concurrency, func(value float64) {
+							mu.Lock()
+							st.Append(value)
+							mu.Unlock()
+						}, func() {
+							mu.Lock()
+							st.Mean()
+							st.StdDev()
+							mu.Unlock()
+						})
+						st.CleanPool()
+					}
+				})
+				b.Run("ConcurrentSampleStream", func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						st := NewConcurrentSampleStream(0)
+						launchWithConcurrency(concurrency, st.Append, func() {
+							snapshot := st.Snapshot()
+							snapshot.Mean()
+							snapshot.StdDev()
+							snapshot.CleanPool()
+						})
+						st.CleanPool()
+					}
+				})
+			})
+		}
+
+	})
+
 }
@@ -0,0 +1,128 @@
+package gostats
+
+// Merge adds the values of other to s, as if they had been appended to s after its own values, without
+// replaying them. other is not modified.
+//
+// Mean & Variance are combined with the parallel formula of Chan, Golub & LeVeque, and the third and fourth
+// moments with its generalization by Pébay, so the result is the same as appending all the values to a single
+// stream, up to rounding. The streak counters assume that the values of other follow the values of s. The
+// quantiles are merged when both streams have been created with NewSampleStreamWithQuantiles, and so are the
+// trade statistics with NewSampleStreamWithTradeStats.
+//
+// It panics if both streams have trade statistics that do not handle the zero trades the same way, see MergeE.
+func (s *SampleStream) Merge(other *SampleStream) {
+	if err := s.MergeE(other); err != nil {
+		panic(err)
+	}
+}
+
+// MergeE is like Merge, but returns ErrMismatchedTradeStats instead of panicking, s being left unchanged.
+func (s *SampleStream) MergeE(other *SampleStream) error {
+	if s.trades != nil && other.trades != nil && s.trades.zero != other.trades.zero {
+		return ErrMismatchedTradeStats
+	}
+	if other._count == 0 {
+		return nil
+	}
+	if s.digest != nil && other.digest != nil {
+		s.digest.Merge(other.digest)
+	}
+	if s.trades != nil && other.trades != nil {
+		s.trades.Merge(other.trades)
+	}
+	if s._count == 0 {
+		digest, trades := s.digest, s.trades
+		*s = *other
+		s.digest, s.trades = digest, trades
+		return nil
+	}
+
+	n1, n2 := s._count, other._count
+	n := n1 + n2
+	delta := other._prevMean - s._prevMean
+	delta2 := delta * delta
+	m2a, m2b := s._prevVariance, other._prevVariance
+	m3a, m3b := s._m3, other._m3
+
+	s._m4 = s._m4 + other._m4 +
+		delta2*delta2*n1*n2*(n1*n1-n1*n2+n2*n2)/(n*n*n) +
+		6*delta2*(n1*n1*m2b+n2*n2*m2a)/(n*n) +
+		4*delta*(n1*m3b-n2*m3a)/n
+	s._m3 = m3a + m3b +
+		delta2*delta*n1*n2*(n1-n2)/(n*n) +
+		3*delta*(n1*m2b-n2*m2a)/n
+	s._prevVariance = m2a + m2b + delta2*n1*n2/n
+	s._prevMean = s._prevMean + delta*n2/n
+	s._count = n
+	s.sum = s.sum + other.sum
+
+	if other.min < s.min {
+		s.min = other.min
+	}
+	if other.max > s.max {
+		s.max = other.max
+	}
+
+	s.nbPositives = s.nbPositives + other.nbPositives
+	s.nbProfitStreak = maxStreak(s.nbProfitStreak, other.nbProfitStreak, s._nbPositiveStreak+other._leadingPositiveStreak)
+	s.nbLossStreak = maxStreak(s.nbLossStreak, other.nbLossStreak, s._nbNegativeStreak+other._leadingNegativeStreak)
+	if float64(s._leadingPositiveStreak) == n1 {
+		s._leadingPositiveStreak = s._leadingPositiveStreak + other._leadingPositiveStreak
+	}
+	if float64(s._leadingNegativeStreak) == n1 {
+		s._leadingNegativeStreak = s._leadingNegativeStreak + other._leadingNegativeStreak
+	}
+	if float64(other._nbPositiveStreak) == n2 {
+		s._nbPositiveStreak = s._nbPositiveStreak + other._nbPositiveStreak
+	} else {
+		s._nbPositiveStreak = other._nbPositiveStreak
+	}
+	if float64(other._nbNegativeStreak) == n2 {
+		s._nbNegativeStreak = s._nbNegativeStreak + other._nbNegativeStreak
+	} else {
+		s._nbNegativeStreak = other._nbNegativeStreak
+	}
+	return nil
+}
+
+// MergeSampleStreams combines streams into a new SampleStream, taken from the pool, by merging them pairwise
+// as a tree, which keeps rounding errors low when combining many workers. The streams are considered in order,
+// and are not modified. It panics like Merge.
+func MergeSampleStreams(streams ...*SampleStream) *SampleStream {
+	result := NewSampleStream()
+	if len(streams) == 0 {
+		return result
+	}
+
+	level := make([]SampleStream, len(streams))
+	for i, stream := range streams {
+		level[i] = *stream
+		if stream.digest != nil {
+			level[i].digest = stream.digest.Clone()
+		}
+		if stream.trades != nil {
+			level[i].trades = stream.trades.Clone()
+		}
+	}
+	for len(level) > 1 {
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				level[i].Merge(&level[i+1])
+			}
+			level[i/2] = level[i]
+		}
+		level = level[:(len(level)+1)/2]
+	}
+	*result = level[0]
+	return result
+}
+
+func maxStreak(streaks ...int16) int16 {
+	max := streaks[0]
+	for _, streak := range streaks[1:] {
+		if streak > max {
+			max = streak
+		}
+	}
+	return max
+}
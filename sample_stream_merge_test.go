@@ -0,0 +1,129 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math/rand"
+	"testing"
+)
+
+func TestSampleStreamMerge(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 500)
+	for i := range values {
+		values[i] = float64(r.Intn(7)-3) + r.Float64()/2
+	}
+
+	t.Run("Merge", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, split := range []int{0, 1, 2, 7, 250, 499, 500} {
+			want := NewSampleStream()
+			want.AppendMany(values)
+
+			got := NewSampleStream()
+			got.AppendMany(values[:split])
+			other := NewSampleStream()
+			other.AppendMany(values[split:])
+			got.Merge(other)
+			expectSameStream(g, got, want)
+			g.Expect(other.Len()).To(Equal(len(values) - split))
+
+			want.CleanPool()
+			got.CleanPool()
+			other.CleanPool()
+		}
+	})
+
+	t.Run("Streaks across the merge", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		for _, c := range [][]float64{
+			{1, 1, -1, 1, 1, 1, 1, -1},
+			{1, 1, 1, 1, 1, 1},
+			{-1, -1, -1, 1, -1, -1, -1, -1},
+		} {
+			for split := 0; split <= len(c); split++ {
+				want := NewSampleStream()
+				want.AppendMany(c)
+				got := NewSampleStream()
+				got.AppendMany(c[:split])
+				other := NewSampleStream()
+				other.AppendMany(c[split:])
+				got.Merge(other)
+				expectSameStreaks(g, got, want)
+			}
+		}
+	})
+
+	t.Run("MergeSampleStreams", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		want := NewSampleStream()
+		want.AppendMany(values)
+
+		workers := make([]*SampleStream, 0)
+		for i := 0; i < len(values); i += 70 {
+			end := i + 70
+			if end > len(values) {
+				end = len(values)
+			}
+			worker := NewSampleStream()
+			worker.AppendMany(values[i:end])
+			workers = append(workers, worker)
+		}
+		got := MergeSampleStreams(workers...)
+		expectSameStream(g, got, want)
+		g.Expect(workers[0].Len()).To(Equal(70))
+
+		empty := MergeSampleStreams()
+		g.Expect(empty.Len()).To(Equal(0))
+	})
+
+	t.Run("Mismatched trade stats", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleStreamWithTradeStats(ZeroTradesAsLoss)
+		s.AppendMany([]float64{1, 0, -2})
+		other := NewSampleStreamWithTradeStats(ZeroTradesExcluded)
+		other.AppendMany([]float64{3, 0})
+
+		g.Expect(s.MergeE(other)).To(Equal(ErrMismatchedTradeStats))
+		g.Expect(s.Len()).To(Equal(3))
+		g.Expect(s.TradeStats().Count()).To(Equal(3))
+		g.Expect(func() { s.Merge(other) }).To(PanicWith(ErrMismatchedTradeStats))
+		g.Expect(func() { MergeSampleStreams(s, other) }).To(Panic())
+		g.Expect(s.Len()).To(Equal(3))
+
+		// Streams without trade statistics merge with any of them.
+		plain := NewSampleStream()
+		plain.AppendMany([]float64{5})
+		g.Expect(s.MergeE(plain)).To(Succeed())
+		g.Expect(s.Len()).To(Equal(4))
+		g.Expect(s.TradeStats().Count()).To(Equal(3))
+
+		s.CleanPool()
+		other.CleanPool()
+		plain.CleanPool()
+	})
+
+}
+
+func expectSameStream(g *GomegaWithT, got, want *SampleStream) {
+	g.Expect(got.Sum()).To(BeNumerically("~", want.Sum(), 1e-9))
+	g.Expect(got.Mean()).To(BeNumerically("~", want.Mean(), 1e-12))
+	g.Expect(got.Variance()).To(BeNumerically("~", want.Variance(), 1e-9))
+	g.Expect(got.Skewness()).To(BeNumerically("~", want.Skewness(), 1e-9))
+	g.Expect(got.Kurtosis()).To(BeNumerically("~", want.Kurtosis(), 1e-9))
+	gmin, gmax := got.Bounds()
+	wmin, wmax := want.Bounds()
+	g.Expect([]float64{gmin, gmax}).To(Equal([]float64{wmin, wmax}))
+	expectSameStreaks(g, got, want)
+}
+
+func expectSameStreaks(g *GomegaWithT, got, want *SampleStream) {
+	g.Expect(got.Len()).To(Equal(want.Len()))
+	g.Expect(got.nbPositives).To(Equal(want.nbPositives))
+	g.Expect(got.nbProfitStreak).To(Equal(want.nbProfitStreak))
+	g.Expect(got.nbLossStreak).To(Equal(want.nbLossStreak))
+	g.Expect(got._nbPositiveStreak).To(Equal(want._nbPositiveStreak))
+	g.Expect(got._nbNegativeStreak).To(Equal(want._nbNegativeStreak))
+	g.Expect(got._leadingPositiveStreak).To(Equal(want._leadingPositiveStreak))
+	g.Expect(got._leadingNegativeStreak).To(Equal(want._leadingNegativeStreak))
+}
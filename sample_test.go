@@ -128,6 +128,38 @@ func TestMyStats(t *testing.T) {
 		g.Expect(math.IsNaN(s.StandardDeviationPopulation())).To(BeTrue())
 	})
 
+	t.Run("Skewness/Kurtosis", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue([]float64{2, 8, 0, 4, 1, 9, 9, 0}, false)
+		g.Expect(s.Skewness()).To(BeNumerically("~", 0.2650554122698573, 1e-12))
+		g.Expect(s.Kurtosis()).To(BeNumerically("~", -1.6660010752838508, 1e-12))
+		g.Expect(s.SkewnessAdjusted()).To(BeNumerically("~", 0.33058218040797466, 1e-12))
+		g.Expect(s.KurtosisAdjusted()).To(BeNumerically("~", -2.098602258096087, 1e-12))
+
+		s = NewSampleWithValue([]float64{1, 2}, false)
+		g.Expect(s.Skewness()).To(Equal(0.0))
+		g.Expect(math.IsNaN(s.SkewnessAdjusted())).To(BeTrue())
+		g.Expect(math.IsNaN(s.KurtosisAdjusted())).To(BeTrue())
+
+		s = NewSampleWithValue([]float64{}, false)
+		g.Expect(math.IsNaN(s.Skewness())).To(BeTrue())
+		g.Expect(math.IsNaN(s.Kurtosis())).To(BeTrue())
+		s = NewSampleWithValue([]float64{3, 3, 3}, false)
+		g.Expect(math.IsNaN(s.Skewness())).To(BeTrue())
+		g.Expect(math.IsNaN(s.Kurtosis())).To(BeTrue())
+
+		// The moments follow the values appended after they have been computed.
+		s.Append(4)
+		expected := NewSampleWithValue([]float64{3, 3, 3, 4}, false)
+		g.Expect(s.Skewness()).To(Equal(expected.Skewness()))
+		g.Expect(s.Kurtosis()).To(Equal(expected.Kurtosis()))
+		s.AppendMany([]float64{2, 8, 0})
+		expected = NewSampleWithValue([]float64{3, 3, 3, 4, 2, 8, 0}, false)
+		g.Expect(s.Skewness()).To(BeNumerically("~", expected.Skewness(), 1e-12))
+		g.Expect(s.Kurtosis()).To(BeNumerically("~", expected.Kurtosis(), 1e-12))
+		g.Expect(s.Mean()).To(BeNumerically("~", expected.Mean(), 1e-12))
+	})
+
 	//t.Run("", func(t *testing.T) {
 	//	g := NewGomegaWithT(t)
 	//})
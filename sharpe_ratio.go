@@ -0,0 +1,94 @@
+package gostats
+
+import (
+	"math"
+
+	"github.com/a-lucas/go-stats/stats"
+)
+
+// eulerMascheroni is the Euler-Mascheroni constant γ.
+const eulerMascheroni = 0.57721566490153286060651209008240243104215933593992
+
+// SharpeRatioTest is the probability that the true Sharpe ratio of a series of returns exceeds a benchmark,
+// given its length, skewness and kurtosis (Bailey & López de Prado). Sharpe ratios are per period, not
+// annualized.
+type SharpeRatioTest struct {
+	// N is the number of returns.
+	N int
+	// Sharpe is the observed Sharpe ratio, the mean of the returns divided by their sample standard deviation.
+	Sharpe float64
+	// Benchmark is the Sharpe ratio threshold the observed one is compared to.
+	Benchmark float64
+	// Probability is the probability that the true Sharpe ratio is above Benchmark.
+	Probability float64
+}
+
+func nanSharpeRatioTest(n int, benchmark float64) *SharpeRatioTest {
+	return &SharpeRatioTest{N: n, Sharpe: math.NaN(), Benchmark: benchmark, Probability: math.NaN()}
+}
+
+// ProbabilisticSharpeRatio returns the Probabilistic Sharpe Ratio of the Sample, its values being excess
+// returns: the probability that their true Sharpe ratio is above benchmark, which is
+// Φ((SR - benchmark) √(n - 1) / √(1 - g1 SR + (g2 + 2) / 4 SR²)), g1 being the skewness and g2 the excess
+// kurtosis of the returns. It is NaN for less than 2 values, or when the Sample is constant.
+func (s *Sample) ProbabilisticSharpeRatio(benchmark float64) *SharpeRatioTest {
+	res, err := s.ProbabilisticSharpeRatioE(benchmark)
+	if err != nil {
+		return nanSharpeRatioTest(s.nb, benchmark)
+	}
+	return res
+}
+
+// ProbabilisticSharpeRatioE is like ProbabilisticSharpeRatio, but returns ErrSampleSize for less than 2
+// values.
+func (s *Sample) ProbabilisticSharpeRatioE(benchmark float64) (*SharpeRatioTest, error) {
+	if s.nb < 2 {
+		return nil, ErrSampleSize
+	}
+	sharpe := s.Mean() / s.StdDev()
+	skew, kurt := s.Skewness(), s.Kurtosis()
+	sd := math.Sqrt(1 - skew*sharpe + (kurt+2)/4*sharpe*sharpe)
+	return &SharpeRatioTest{
+		N:           s.nb,
+		Sharpe:      sharpe,
+		Benchmark:   benchmark,
+		Probability: stats.StdNormal.CDF((sharpe - benchmark) * math.Sqrt(float64(s.nb-1)) / sd),
+	}, nil
+}
+
+// DeflatedSharpeRatio returns the Deflated Sharpe Ratio of the Sample, the best of a number of trials, such as
+// strategy variants tested on the same data, whose Sharpe ratios have the given variance. It is the
+// Probabilistic Sharpe Ratio against the Sharpe ratio expected from the best of the trials when all their true
+// Sharpe ratios are zero: √V ((1 - γ) Φ⁻¹(1 - 1/N) + γ Φ⁻¹(1 - 1/(N e))), γ being the Euler-Mascheroni
+// constant.
+//
+// It panics if trials < 2 or variance < 0, see DeflatedSharpeRatioE. It is NaN for less than 2 values.
+func (s *Sample) DeflatedSharpeRatio(trials int, variance float64) *SharpeRatioTest {
+	res, err := s.DeflatedSharpeRatioE(trials, variance)
+	switch err {
+	case nil:
+		return res
+	case ErrSampleSize:
+		return nanSharpeRatioTest(s.nb, ExpectedMaxSharpeRatio(trials, variance))
+	}
+	panic(err)
+}
+
+// DeflatedSharpeRatioE is like DeflatedSharpeRatio, but returns an error instead of panicking.
+func (s *Sample) DeflatedSharpeRatioE(trials int, variance float64) (*SharpeRatioTest, error) {
+	if trials < 2 || !(variance >= 0) {
+		return nil, ErrInvalidTrials
+	}
+	return s.ProbabilisticSharpeRatioE(ExpectedMaxSharpeRatio(trials, variance))
+}
+
+// ExpectedMaxSharpeRatio returns the expected maximum of the Sharpe ratios of a number of trials whose true
+// Sharpe ratios are zero, the benchmark of DeflatedSharpeRatio. It is NaN if trials < 2 or variance < 0.
+func ExpectedMaxSharpeRatio(trials int, variance float64) float64 {
+	if trials < 2 || !(variance >= 0) {
+		return math.NaN()
+	}
+	n := float64(trials)
+	return math.Sqrt(variance) * ((1-eulerMascheroni)*stats.StdNormal.InvCDF(1-1/n) +
+		eulerMascheroni*stats.StdNormal.InvCDF(1-1/(n*math.E)))
+}
@@ -0,0 +1,59 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"testing"
+)
+
+func TestSharpeRatio(t *testing.T) {
+
+	returns := []float64{0.021, -0.013, 0.034, 0.008, -0.027, 0.015, 0.042, -0.006, 0.011, 0.019, -0.031, 0.026}
+
+	t.Run("Probabilistic", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue(returns, false)
+		res := s.ProbabilisticSharpeRatio(0)
+		g.Expect(res.N).To(Equal(12))
+		g.Expect(res.Sharpe).To(BeNumerically("~", 0.356640729440629, 1e-12))
+		g.Expect(res.Benchmark).To(Equal(0.0))
+		g.Expect(res.Probability).To(BeNumerically("~", 0.8622062754131408, 1e-9))
+		g.Expect(s.ProbabilisticSharpeRatio(0.1).Probability).To(BeNumerically("~", 0.78364879883985, 1e-9))
+		g.Expect(s.ProbabilisticSharpeRatio(res.Sharpe).Probability).To(BeNumerically("~", 0.5, 1e-12))
+	})
+
+	t.Run("Deflated", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(ExpectedMaxSharpeRatio(100, 0.01)).To(BeNumerically("~", 0.2530602893201685, 1e-9))
+		g.Expect(ExpectedMaxSharpeRatio(10, 0.04)).To(BeNumerically("~", 0.31491966026915, 1e-9))
+		g.Expect(ExpectedMaxSharpeRatio(1000, 0.01)).To(BeNumerically(">", ExpectedMaxSharpeRatio(100, 0.01)))
+		g.Expect(math.IsNaN(ExpectedMaxSharpeRatio(1, 0.01))).To(BeTrue())
+
+		s := NewSampleWithValue(returns, false)
+		res := s.DeflatedSharpeRatio(100, 0.01)
+		g.Expect(res.Benchmark).To(BeNumerically("~", 0.2530602893201685, 1e-9))
+		g.Expect(res.Probability).To(BeNumerically("~", 0.6242474823687915, 1e-9))
+		g.Expect(res.Probability).To(BeNumerically("<", s.ProbabilisticSharpeRatio(0).Probability))
+		g.Expect(s.DeflatedSharpeRatio(2, 0).Benchmark).To(Equal(0.0))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue([]float64{0.01}, false)
+		_, err := s.ProbabilisticSharpeRatioE(0)
+		g.Expect(err).To(Equal(ErrSampleSize))
+		g.Expect(math.IsNaN(s.ProbabilisticSharpeRatio(0).Probability)).To(BeTrue())
+		res := s.DeflatedSharpeRatio(10, 0.04)
+		g.Expect(math.IsNaN(res.Probability)).To(BeTrue())
+		g.Expect(res.Benchmark).To(BeNumerically("~", 0.31491966026915, 1e-9))
+
+		s = NewSampleWithValue(returns, false)
+		_, err = s.DeflatedSharpeRatioE(1, 0.01)
+		g.Expect(err).To(Equal(ErrInvalidTrials))
+		_, err = s.DeflatedSharpeRatioE(10, -1)
+		g.Expect(err).To(Equal(ErrInvalidTrials))
+		g.Expect(func() { s.DeflatedSharpeRatio(0, 0.01) }).To(Panic())
+
+		g.Expect(math.IsNaN(NewSampleWithValue([]float64{0.01, 0.01, 0.01}, false).ProbabilisticSharpeRatio(0).Probability)).To(BeTrue())
+	})
+}
@@ -0,0 +1,53 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Rank returns the fractional ranks of xs, where the smallest value
+// has rank 1 and tied values are all assigned the average of the
+// ranks they span. ranks[i] is the rank of xs[i]. NaN values rank
+// after all the others, and never tie.
+//
+// It also returns the tie vector T in the same form used by
+// MannWhitneyUTest: T[j] is the number of values sharing the j'th
+// distinct rank, in ascending order of value.
+func Rank(xs []float64) (ranks []float64, T []int) {
+	order := make([]int, len(xs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		x, y := xs[order[i]], xs[order[j]]
+		return x < y || math.IsNaN(y) && !math.IsNaN(x)
+	})
+
+	ranks = make([]float64, len(xs))
+	for i := 0; i < len(order); {
+		rank1, v1 := i+1, xs[order[i]]
+		// Consume values that tie this value (including itself, even
+		// if it is NaN).
+		j := i + 1
+		for ; j < len(order) && xs[order[j]] == v1; j++ {
+		}
+		// Assign all tied values the average rank of the
+		// values, where order[0] has rank 1.
+		rank := float64(j+rank1) / 2
+		for ; i < j; i++ {
+			ranks[order[i]] = rank
+		}
+		T = append(T, j-rank1+1)
+	}
+	return
+}
+
+// TieCorrection computes the tie correction factor Σ_j (t_j³ - t_j)
+// for the tie vector T returned by Rank.
+func TieCorrection(T []int) float64 {
+	return tieCorrection(T)
+}
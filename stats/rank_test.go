@@ -0,0 +1,32 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	check := func(xs, wantRanks []float64, wantT []int, wantTC float64) {
+		ranks, T := Rank(xs)
+		if !reflect.DeepEqual(ranks, wantRanks) || !reflect.DeepEqual(T, wantT) {
+			t.Errorf("Rank(%v): want %v %v, got %v %v", xs, wantRanks, wantT, ranks, T)
+		}
+		if tc := TieCorrection(T); tc != wantTC {
+			t.Errorf("TieCorrection(%v): want %v, got %v", T, wantTC, tc)
+		}
+	}
+
+	check([]float64{3, 1, 2}, []float64{3, 1, 2}, []int{1, 1, 1}, 0)
+	check([]float64{10, 20, 10, 30}, []float64{1.5, 3, 1.5, 4}, []int{2, 1, 1}, 6)
+	check([]float64{5, 5, 5}, []float64{2, 2, 2}, []int{3}, 24)
+	check([]float64{}, []float64{}, nil, 0)
+
+	nan := math.NaN()
+	check([]float64{nan, 2, nan, 1, 2}, []float64{4, 2.5, 5, 1, 2.5}, []int{1, 2, 1, 1}, 6)
+	check([]float64{nan}, []float64{1}, []int{1}, 0)
+}
@@ -0,0 +1,308 @@
+package gostats
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultTDigestCompression is a compression giving a rank error below 1.6% at the median, and below 0.32%
+// for the 1st and 99th percentiles, with at most 100 centroids.
+const DefaultTDigestCompression = 100
+
+const tDigestEncodingVersion = 1
+
+var TDigestPool = sync.Pool{
+	New: func() interface{} { return new(TDigest) },
+}
+
+// TDigest is a merging t-digest (Dunning & Ertl, 2019), a sketch of the distribution of a stream of values
+// answering quantile and CDF queries with bounded memory. Digests can be merged, so they can be built in
+// parallel.
+//
+// Values are grouped into centroids whose size is limited by the k1 scale function with the compression δ: a
+// centroid spans at most 2π√(q(1-q))/δ of the rank, so the rank error of Quantile and CDF is about half of that.
+// It is much smaller at the tails than around the median, and the minimum and maximum are exact. A digest uses
+// at most δ centroids, plus a buffer of 5δ values.
+//
+// Quantile, CDF and MarshalBinary first merge the buffer into the centroids, so they modify the digest: like
+// Append, they must not be called concurrently, even with each other.
+type TDigest struct {
+	compression float64
+	centroids   tDigestCentroids
+	buffer      tDigestCentroids
+	scratch     tDigestCentroids
+	count       float64
+	min         float64
+	max         float64
+}
+
+type tDigestCentroid struct {
+	mean   float64
+	weight float64
+}
+
+type tDigestCentroids []tDigestCentroid
+
+func (c tDigestCentroids) Len() int           { return len(c) }
+func (c tDigestCentroids) Less(i, j int) bool { return c[i].mean < c[j].mean }
+func (c tDigestCentroids) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// NewTDigest returns an empty TDigest with the given compression. It panics if compression < 1.
+func NewTDigest(compression float64) *TDigest {
+	if !(compression >= 1) {
+		panic("TDigest compression must be >= 1")
+	}
+	t := TDigestPool.Get().(*TDigest)
+	t.initEmptyValues(compression)
+	return t
+}
+
+func (t *TDigest) CleanPool() {
+	TDigestPool.Put(t)
+}
+
+func (t *TDigest) initEmptyValues(compression float64) {
+	t.compression = compression
+	bufferSize := int(math.Ceil(5 * compression))
+	if cap(t.buffer) < bufferSize {
+		t.buffer = make(tDigestCentroids, 0, bufferSize)
+	}
+	t.centroids = t.centroids[:0]
+	t.buffer = t.buffer[:0]
+	t.count = 0
+	t.min = math.Inf(+1)
+	t.max = math.Inf(-1)
+}
+
+// Clone returns a copy of t, taken from the pool.
+func (t *TDigest) Clone() *TDigest {
+	c := NewTDigest(t.compression)
+	c.centroids = append(c.centroids, t.centroids...)
+	c.buffer = append(c.buffer, t.buffer...)
+	c.count = t.count
+	c.min = t.min
+	c.max = t.max
+	return c
+}
+
+func (t *TDigest) Append(value float64) {
+	t.AppendWeighted(value, 1)
+}
+
+func (t *TDigest) AppendMany(values []float64) {
+	for _, value := range values {
+		t.AppendWeighted(value, 1)
+	}
+}
+
+// AppendWeighted adds a value with the given weight, which must be positive.
+func (t *TDigest) AppendWeighted(value, weight float64) {
+	if len(t.buffer) == cap(t.buffer) {
+		t.compress()
+	}
+	t.buffer = append(t.buffer, tDigestCentroid{value, weight})
+	t.count = t.count + weight
+	if value < t.min {
+		t.min = value
+	}
+	if value > t.max {
+		t.max = value
+	}
+}
+
+// Merge adds the values of other to t. other is not modified.
+func (t *TDigest) Merge(other *TDigest) {
+	for _, c := range other.centroids {
+		t.AppendWeighted(c.mean, c.weight)
+	}
+	for _, c := range other.buffer {
+		t.AppendWeighted(c.mean, c.weight)
+	}
+}
+
+// Compression returns the compression δ of the digest.
+func (t *TDigest) Compression() float64 {
+	return t.compression
+}
+
+// Count returns the total weight of the appended values.
+func (t *TDigest) Count() float64 {
+	return t.count
+}
+
+// Bounds returns the minimum and maximum values appended to the digest.
+func (t *TDigest) Bounds() (min float64, max float64) {
+	if t.count == 0 {
+		return math.NaN(), math.NaN()
+	}
+	return t.min, t.max
+}
+
+// compress merges the buffer into the centroids, merging adjacent centroids as long as their size does not
+// exceed one unit of the k1 scale function.
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	sort.Sort(t.buffer)
+
+	// Merge the sorted buffer with the sorted centroids into scratch.
+	all := t.scratch[:0]
+	i, j := 0, 0
+	for i < len(t.centroids) && j < len(t.buffer) {
+		if t.buffer[j].mean < t.centroids[i].mean {
+			all = append(all, t.buffer[j])
+			j++
+		} else {
+			all = append(all, t.centroids[i])
+			i++
+		}
+	}
+	all = append(all, t.centroids[i:]...)
+	all = append(all, t.buffer[j:]...)
+
+	out := t.centroids[:0]
+	current := all[0]
+	weightSoFar := 0.0
+	limit := t.count * t.qOfK(t.kOfQ(0)+1)
+	for _, c := range all[1:] {
+		if weightSoFar+current.weight+c.weight <= limit {
+			current.weight = current.weight + c.weight
+			current.mean = current.mean + (c.mean-current.mean)*c.weight/current.weight
+			continue
+		}
+		weightSoFar = weightSoFar + current.weight
+		out = append(out, current)
+		limit = t.count * t.qOfK(t.kOfQ(weightSoFar/t.count)+1)
+		current = c
+	}
+	out = append(out, current)
+
+	t.centroids = out
+	t.scratch = all
+	t.buffer = t.buffer[:0]
+}
+
+// kOfQ is the k1 scale function, mapping [0, 1] to [-δ/4, δ/4].
+func (t *TDigest) kOfQ(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// qOfK is the inverse of kOfQ.
+func (t *TDigest) qOfK(k float64) float64 {
+	if k >= t.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/t.compression) + 1) / 2
+}
+
+// Quantile returns the estimated value below which a fraction q of the values fall, interpolating between the
+// centers of the centroids. It is NaN if the digest is empty. It compresses the digest, see TDigest.
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	if t.count == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+
+	c := t.centroids
+	n := len(c)
+	index := q * t.count
+	if index < c[0].weight/2 {
+		return t.min + index/(c[0].weight/2)*(c[0].mean-t.min)
+	}
+	weightSoFar := c[0].weight / 2
+	for i := 0; i < n-1; i++ {
+		dw := (c[i].weight + c[i+1].weight) / 2
+		if weightSoFar+dw > index {
+			return c[i].mean + (index-weightSoFar)/dw*(c[i+1].mean-c[i].mean)
+		}
+		weightSoFar = weightSoFar + dw
+	}
+	return c[n-1].mean + (index-weightSoFar)/(c[n-1].weight/2)*(t.max-c[n-1].mean)
+}
+
+// CDF returns the estimated fraction of the values that are less than or equal to x. It is NaN if the digest
+// is empty. It compresses the digest, see TDigest.
+func (t *TDigest) CDF(x float64) float64 {
+	t.compress()
+	if t.count == 0 {
+		return math.NaN()
+	}
+	if x < t.min {
+		return 0
+	}
+	if x >= t.max {
+		return 1
+	}
+
+	c := t.centroids
+	n := len(c)
+	if x < c[0].mean {
+		return c[0].weight / 2 * (x - t.min) / (c[0].mean - t.min) / t.count
+	}
+	weightSoFar := c[0].weight / 2
+	for i := 0; i < n-1; i++ {
+		dw := (c[i].weight + c[i+1].weight) / 2
+		if x < c[i+1].mean {
+			return (weightSoFar + dw*(x-c[i].mean)/(c[i+1].mean-c[i].mean)) / t.count
+		}
+		weightSoFar = weightSoFar + dw
+	}
+	return (weightSoFar + c[n-1].weight/2*(x-c[n-1].mean)/(t.max-c[n-1].mean)) / t.count
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (t *TDigest) MarshalBinary() ([]byte, error) {
+	t.compress()
+	data := make([]byte, 1+4*8+4+16*len(t.centroids))
+	data[0] = tDigestEncodingVersion
+	binary.BigEndian.PutUint64(data[1:], math.Float64bits(t.compression))
+	binary.BigEndian.PutUint64(data[9:], math.Float64bits(t.count))
+	binary.BigEndian.PutUint64(data[17:], math.Float64bits(t.min))
+	binary.BigEndian.PutUint64(data[25:], math.Float64bits(t.max))
+	binary.BigEndian.PutUint32(data[33:], uint32(len(t.centroids)))
+	o := 37
+	for _, c := range t.centroids {
+		binary.BigEndian.PutUint64(data[o:], math.Float64bits(c.mean))
+		binary.BigEndian.PutUint64(data[o+8:], math.Float64bits(c.weight))
+		o += 16
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (t *TDigest) UnmarshalBinary(data []byte) error {
+	if len(data) < 37 {
+		return ErrInvalidEncoding
+	}
+	if data[0] != tDigestEncodingVersion {
+		return ErrUnsupportedVersion
+	}
+	compression := math.Float64frombits(binary.BigEndian.Uint64(data[1:]))
+	n := int(binary.BigEndian.Uint32(data[33:]))
+	if !(compression >= 1) || len(data) != 37+16*n {
+		return ErrInvalidEncoding
+	}
+
+	t.initEmptyValues(compression)
+	t.count = math.Float64frombits(binary.BigEndian.Uint64(data[9:]))
+	t.min = math.Float64frombits(binary.BigEndian.Uint64(data[17:]))
+	t.max = math.Float64frombits(binary.BigEndian.Uint64(data[25:]))
+	o := 37
+	for i := 0; i < n; i++ {
+		t.centroids = append(t.centroids, tDigestCentroid{
+			mean:   math.Float64frombits(binary.BigEndian.Uint64(data[o:])),
+			weight: math.Float64frombits(binary.BigEndian.Uint64(data[o+8:])),
+		})
+		o += 16
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestTDigest(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 100000)
+	for i := range values {
+		values[i] = r.NormFloat64()
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := func(x float64) float64 {
+		return float64(sort.SearchFloat64s(sorted, x)) / float64(len(sorted))
+	}
+
+	t.Run("Small", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewTDigest(DefaultTDigestCompression)
+		g.Expect(math.IsNaN(d.Quantile(0.5))).To(BeTrue())
+		g.Expect(math.IsNaN(d.CDF(0))).To(BeTrue())
+		d.AppendMany([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+		g.Expect(d.Quantile(0.5)).To(Equal(5.5))
+		g.Expect(d.Quantile(0)).To(Equal(1.0))
+		g.Expect(d.Quantile(1)).To(Equal(10.0))
+		g.Expect(d.CDF(0)).To(Equal(0.0))
+		g.Expect(d.CDF(5.5)).To(Equal(0.5))
+		g.Expect(d.CDF(10)).To(Equal(1.0))
+		d.CleanPool()
+	})
+
+	t.Run("Error bounds", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewTDigest(DefaultTDigestCompression)
+		d.AppendMany(values)
+		g.Expect(d.Count()).To(Equal(float64(len(values))))
+		for _, q := range []float64{0.001, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999} {
+			bound := math.Pi * math.Sqrt(q*(1-q)) / d.Compression()
+			g.Expect(rank(d.Quantile(q))).To(BeNumerically("~", q, bound))
+			x := sorted[int(q*float64(len(sorted)))]
+			g.Expect(d.CDF(x)).To(BeNumerically("~", q, bound))
+		}
+		d.compress()
+		g.Expect(len(d.centroids)).To(BeNumerically("<=", DefaultTDigestCompression))
+		min, max := d.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{sorted[0], sorted[len(sorted)-1]}))
+		d.CleanPool()
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewTDigest(DefaultTDigestCompression)
+		for i := 0; i < len(values); i += 10000 {
+			part := NewTDigest(DefaultTDigestCompression)
+			part.AppendMany(values[i : i+10000])
+			d.Merge(part)
+			part.CleanPool()
+		}
+		g.Expect(d.Count()).To(Equal(float64(len(values))))
+		for _, q := range []float64{0.01, 0.5, 0.99} {
+			g.Expect(rank(d.Quantile(q))).To(BeNumerically("~", q, 2*math.Pi*math.Sqrt(q*(1-q))/d.Compression()))
+		}
+		d.CleanPool()
+	})
+
+	t.Run("Binary encoding", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		d := NewTDigest(50)
+		d.AppendMany(values[:5000])
+		data, err := d.MarshalBinary()
+		g.Expect(err).To(BeNil())
+
+		decoded := NewTDigest(DefaultTDigestCompression)
+		g.Expect(decoded.UnmarshalBinary(data)).To(Succeed())
+		g.Expect(decoded.Compression()).To(Equal(50.0))
+		g.Expect(decoded.Count()).To(Equal(d.Count()))
+		for _, q := range []float64{0, 0.01, 0.5, 0.99, 1} {
+			g.Expect(decoded.Quantile(q)).To(Equal(d.Quantile(q)))
+		}
+		g.Expect(decoded.UnmarshalBinary(data[:20])).To(Equal(ErrInvalidEncoding))
+		data[0] = 99
+		g.Expect(decoded.UnmarshalBinary(data)).To(Equal(ErrUnsupportedVersion))
+	})
+
+	t.Run("SampleStream", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleStream()
+		g.Expect(math.IsNaN(s.Quantile(0.5))).To(BeTrue())
+		s.CleanPool()
+
+		s = NewSampleStreamWithQuantiles(DefaultTDigestCompression)
+		s.AppendMany(values[:50000])
+		other := NewSampleStreamWithQuantiles(DefaultTDigestCompression)
+		other.AppendMany(values[50000:])
+		s.Merge(other)
+		g.Expect(s.Digest().Count()).To(Equal(float64(len(values))))
+		g.Expect(rank(s.Quantile(0.99))).To(BeNumerically("~", 0.99, 0.005))
+		g.Expect(s.CDF(0)).To(BeNumerically("~", rank(0), 0.02))
+
+		merged := MergeSampleStreams(s, other)
+		g.Expect(merged.Digest().Count()).To(Equal(float64(len(values) + 50000)))
+		g.Expect(s.Digest().Count()).To(Equal(float64(len(values))))
+		merged.CleanPool()
+		s.CleanPool()
+		other.CleanPool()
+	})
+
+}
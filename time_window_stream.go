@@ -0,0 +1,255 @@
+package gostats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+var TimeWindowStreamPool = sync.Pool{
+	New: func() interface{} { return new(TimeWindowStream) },
+}
+
+// TimeWindowStream is a SampleStream restricted to the values appended during the last `window` duration:
+// with T the latest timestamp seen, it holds the values whose timestamp is in (T - window, T].
+//
+// Values may arrive out of order, as long as they are not older than T - lateness: later values are dropped,
+// and counted by Dropped. In order values are appended and evicted in O(1) amortized, late values are
+// inserted in O(log n + k), k being the number of values more recent than the late one.
+type TimeWindowStream struct {
+	window   int64
+	lateness int64
+	// times & values are sorted by time, the window being times[head:]
+	times  []int64
+	values []float64
+	head   int
+	latest int64
+	// internal values
+	moments slidingMoments
+	mins    timedDeque
+	maxs    timedDeque
+	dropped int
+	// streaming
+	_initialized bool
+	_sinceReSync int
+}
+
+// NewTimeWindowStream returns a TimeWindowStream over the given window, accepting values up to lateness late.
+// It panics if window is not positive or lateness is negative.
+func NewTimeWindowStream(window, lateness time.Duration) *TimeWindowStream {
+	if window <= 0 {
+		panic("TimeWindowStream window must be positive")
+	}
+	if lateness < 0 {
+		panic("TimeWindowStream lateness must not be negative")
+	}
+	s := TimeWindowStreamPool.Get().(*TimeWindowStream)
+	s.initEmptyValues(window, lateness)
+	return s
+}
+
+func (s *TimeWindowStream) CleanPool() {
+	TimeWindowStreamPool.Put(s)
+}
+
+func (s *TimeWindowStream) initEmptyValues(window, lateness time.Duration) {
+	s.window = int64(window)
+	s.lateness = int64(lateness)
+	s.times = s.times[:0]
+	s.values = s.values[:0]
+	s.head = 0
+	s.latest = 0
+	s.moments = slidingMoments{}
+	s.mins.init(func(a, b float64) bool { return a <= b })
+	s.maxs.init(func(a, b float64) bool { return a >= b })
+	s.dropped = 0
+	s._initialized = false
+	s._sinceReSync = 0
+}
+
+// Append adds a value observed at t. It returns false if the value has been dropped because it is older than
+// the lateness tolerance or than the window.
+func (s *TimeWindowStream) Append(t time.Time, value float64) bool {
+	ts := t.UnixNano()
+	if !s._initialized {
+		s._initialized = true
+		s.latest = ts
+	}
+
+	if ts < s.latest {
+		if ts < s.latest-s.lateness || ts <= s.latest-s.window {
+			s.dropped++
+			return false
+		}
+		s.insert(ts, value)
+	} else {
+		s.times = append(s.times, ts)
+		s.values = append(s.values, value)
+		s.latest = ts
+	}
+	s.moments.add(value)
+	s.mins.insert(ts, value)
+	s.maxs.insert(ts, value)
+	s.evict()
+	return true
+}
+
+// Advance moves the window forward to now, evicting the values older than now - window, as if a value had been
+// appended at now. It does nothing if now is not after the latest timestamp.
+func (s *TimeWindowStream) Advance(now time.Time) {
+	ts := now.UnixNano()
+	if s._initialized && ts <= s.latest {
+		return
+	}
+	s._initialized = true
+	s.latest = ts
+	s.evict()
+}
+
+// insert adds a late value at its place in times & values.
+func (s *TimeWindowStream) insert(ts int64, value float64) {
+	live := s.times[s.head:]
+	i := s.head + sort.Search(len(live), func(i int) bool { return live[i] > ts })
+	s.times = append(s.times, 0)
+	s.values = append(s.values, 0)
+	copy(s.times[i+1:], s.times[i:])
+	copy(s.values[i+1:], s.values[i:])
+	s.times[i] = ts
+	s.values[i] = value
+}
+
+// evict removes the values outside of the window.
+func (s *TimeWindowStream) evict() {
+	cutoff := s.latest - s.window
+	for s.head < len(s.times) && s.times[s.head] <= cutoff {
+		s.moments.remove(s.values[s.head])
+		s.head++
+		s._sinceReSync++
+	}
+	s.mins.expire(cutoff)
+	s.maxs.expire(cutoff)
+
+	// Reclaim the evicted space once it is the larger part of the buffers.
+	if s.head > 0 && s.head >= len(s.times)-s.head {
+		n := copy(s.times, s.times[s.head:])
+		copy(s.values, s.values[s.head:])
+		s.times = s.times[:n]
+		s.values = s.values[:n]
+		s.head = 0
+	}
+
+	// To prevent rounding errors from accumulating, recompute the sums once there have been more evictions
+	// than values in the window.
+	if s._sinceReSync > len(s.times)-s.head {
+		s._sinceReSync = 0
+		s.moments = slidingMoments{}
+		for _, value := range s.values[s.head:] {
+			s.moments.add(value)
+		}
+	}
+}
+
+// Len returns the number of values in the window.
+func (s *TimeWindowStream) Len() int {
+	return len(s.times) - s.head
+}
+
+// Dropped returns the number of values that have been dropped for being too late.
+func (s *TimeWindowStream) Dropped() int {
+	return s.dropped
+}
+
+// Latest returns the latest timestamp seen, which is the end of the window.
+func (s *TimeWindowStream) Latest() time.Time {
+	return time.Unix(0, s.latest)
+}
+
+// Bounds returns the minimum and maximum values of the window, or NaN if it is empty.
+func (s *TimeWindowStream) Bounds() (min float64, max float64) {
+	if s.Len() == 0 {
+		return math.NaN(), math.NaN()
+	}
+	return s.mins.front(), s.maxs.front()
+}
+
+// Sum returns the sum of the window.
+func (s *TimeWindowStream) Sum() float64 {
+	return s.moments.sum
+}
+
+// Mean returns the arithmetic mean of the window.
+func (s *TimeWindowStream) Mean() float64 {
+	return s.moments.mean()
+}
+
+func (s *TimeWindowStream) Variance() float64 {
+	return s.moments.variance()
+}
+
+// StdDev returns the sample standard deviation of the window.
+func (s *TimeWindowStream) StdDev() float64 {
+	return math.Pow(s.Variance(), 0.5)
+}
+
+// timedDeque is a monotonic deque of timestamped values, like monotonicDeque, that also accepts values out of
+// time order: its timestamps are increasing, and its values monotonic according to keep.
+type timedDeque struct {
+	times  []int64
+	values []float64
+	head   int
+	keep   func(a, b float64) bool
+}
+
+func (d *timedDeque) init(keep func(a, b float64) bool) {
+	d.times = d.times[:0]
+	d.values = d.values[:0]
+	d.head = 0
+	d.keep = keep
+}
+
+func (d *timedDeque) insert(ts int64, value float64) {
+	live := d.times[d.head:]
+	p := d.head + sort.Search(len(live), func(i int) bool { return live[i] > ts })
+	// A more recent value that is better or equal makes this one useless.
+	if p < len(d.times) && !d.keep(value, d.values[p]) {
+		return
+	}
+	// Older values that are worse are made useless by this one.
+	q := p
+	for q > d.head && !d.keep(d.values[q-1], value) {
+		q--
+	}
+
+	if q == p {
+		d.times = append(d.times, 0)
+		d.values = append(d.values, 0)
+		copy(d.times[p+1:], d.times[p:])
+		copy(d.values[p+1:], d.values[p:])
+	} else if q+1 < p {
+		n := copy(d.times[q+1:], d.times[p:])
+		copy(d.values[q+1:], d.values[p:])
+		d.times = d.times[:q+1+n]
+		d.values = d.values[:q+1+n]
+	}
+	d.times[q] = ts
+	d.values[q] = value
+}
+
+// expire removes the values whose timestamp is <= cutoff.
+func (d *timedDeque) expire(cutoff int64) {
+	for d.head < len(d.times) && d.times[d.head] <= cutoff {
+		d.head++
+	}
+	if d.head > 0 && d.head >= len(d.times)-d.head {
+		n := copy(d.times, d.times[d.head:])
+		copy(d.values, d.values[d.head:])
+		d.times = d.times[:n]
+		d.values = d.values[:n]
+		d.head = 0
+	}
+}
+
+func (d *timedDeque) front() float64 {
+	return d.values[d.head]
+}
@@ -0,0 +1,110 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestTimeWindowStream(t *testing.T) {
+
+	t0 := time.Date(2020, 8, 12, 9, 30, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return t0.Add(time.Duration(seconds) * time.Second)
+	}
+
+	t.Run("Eviction by time", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewTimeWindowStream(10*time.Second, 0)
+		g.Expect(s.Append(at(0), 5)).To(BeTrue())
+		g.Expect(s.Append(at(4), 1)).To(BeTrue())
+		g.Expect(s.Append(at(8), 3)).To(BeTrue())
+		g.Expect(s.Mean()).To(Equal(3.0))
+		g.Expect(s.Variance()).To(Equal(4.0))
+
+		s.Append(at(10), 2)
+		g.Expect(s.Len()).To(Equal(3))
+		g.Expect(s.Sum()).To(Equal(6.0))
+		min, max := s.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{1, 3}))
+
+		s.Advance(at(18))
+		g.Expect(s.Len()).To(Equal(1))
+		g.Expect(s.Mean()).To(Equal(2.0))
+		g.Expect(s.Latest().Equal(at(18))).To(BeTrue())
+
+		s.Advance(at(30))
+		g.Expect(s.Len()).To(Equal(0))
+		min, max = s.Bounds()
+		g.Expect(math.IsNaN(min) && math.IsNaN(max)).To(BeTrue())
+		s.CleanPool()
+	})
+
+	t.Run("Lateness", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewTimeWindowStream(10*time.Second, 3*time.Second)
+		s.Append(at(5), 1)
+		g.Expect(s.Append(at(3), 10)).To(BeTrue())
+		g.Expect(s.Append(at(1), 100)).To(BeFalse())
+		g.Expect(s.Dropped()).To(Equal(1))
+		min, max := s.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{1, 10}))
+
+		// The late value is evicted according to its own timestamp.
+		s.Append(at(13), 2)
+		g.Expect(s.Len()).To(Equal(2))
+		min, max = s.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{1, 2}))
+		s.CleanPool()
+	})
+
+	t.Run("Against Sample", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r := rand.New(rand.NewSource(1))
+		window, lateness := 20, 5
+		s := NewTimeWindowStream(time.Duration(window)*time.Second, time.Duration(lateness)*time.Second)
+
+		type point struct {
+			t int
+			v float64
+		}
+		accepted := make([]point, 0)
+		latest := 0
+		for i := 0; i < 2000; i++ {
+			ts := i/2 - r.Intn(lateness+3)
+			v := r.NormFloat64()*10 + 1000
+			ok := s.Append(at(ts), v)
+			if i == 0 {
+				latest = ts
+			}
+			g.Expect(ok).To(Equal(ts >= latest-lateness && ts > latest-window))
+			if ts > latest {
+				latest = ts
+			}
+			if ok {
+				accepted = append(accepted, point{ts, v})
+			}
+
+			inWindow := make([]float64, 0)
+			for _, p := range accepted {
+				if p.t > latest-window {
+					inWindow = append(inWindow, p.v)
+				}
+			}
+			g.Expect(s.Len()).To(Equal(len(inWindow)))
+			sample := NewSampleWithValue(inWindow, false)
+			g.Expect(s.Mean()).To(BeNumerically("~", sample.Mean(), 1e-9))
+			if len(inWindow) > 1 {
+				g.Expect(s.StdDev()).To(BeNumerically("~", sample.StdDev(), 1e-9))
+			}
+			min, max := s.Bounds()
+			smin, smax := sample.Bounds()
+			g.Expect(min).To(Equal(smin))
+			g.Expect(max).To(Equal(smax))
+		}
+		s.CleanPool()
+	})
+
+}
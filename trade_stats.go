@@ -0,0 +1,291 @@
+package gostats
+
+import (
+	"math"
+	"sync"
+)
+
+// ZeroTrades tells how TradeStats counts the trades with a zero profit & loss.
+type ZeroTrades int
+
+const (
+	// ZeroTradesAsLoss counts the zero trades as losses, like the streak counters of Sample and SampleStream.
+	ZeroTradesAsLoss ZeroTrades = iota
+	// ZeroTradesAsWin counts the zero trades as wins.
+	ZeroTradesAsWin
+	// ZeroTradesExcluded ignores the zero trades, apart from NbExcluded.
+	ZeroTradesExcluded
+)
+
+const tradeStatsEncodingVersion = 1
+
+var TradeStatsPool = sync.Pool{
+	New: func() interface{} { return new(TradeStats) },
+}
+
+// TradeStats sums up the profits & losses of a series of trades, in O(1) per trade. The averages and the
+// largest win and loss are NaN when there is no trade of their kind, and the ratios with a zero denominator are
+// +Inf, or NaN if their numerator is also zero.
+type TradeStats struct {
+	zero        ZeroTrades
+	nbWins      int
+	nbLosses    int
+	nbExcluded  int
+	grossProfit float64
+	// grossLoss is positive
+	grossLoss   float64
+	largestWin  float64
+	largestLoss float64
+}
+
+// NewTradeStats returns an empty TradeStats. It panics if the zero handling is unknown.
+func NewTradeStats(zero ZeroTrades) *TradeStats {
+	if zero < ZeroTradesAsLoss || zero > ZeroTradesExcluded {
+		panic("unknown zero trades handling")
+	}
+	t := TradeStatsPool.Get().(*TradeStats)
+	t.initEmptyValues(zero)
+	return t
+}
+
+func (t *TradeStats) CleanPool() {
+	TradeStatsPool.Put(t)
+}
+
+func (t *TradeStats) initEmptyValues(zero ZeroTrades) {
+	t.zero = zero
+	t.nbWins = 0
+	t.nbLosses = 0
+	t.nbExcluded = 0
+	t.grossProfit = 0
+	t.grossLoss = 0
+	t.largestWin = 0
+	t.largestLoss = 0
+}
+
+// Clone returns a copy of t, taken from the pool.
+func (t *TradeStats) Clone() *TradeStats {
+	c := TradeStatsPool.Get().(*TradeStats)
+	*c = *t
+	return c
+}
+
+// TradeStats returns the trade statistics of the values of the Sample, each of them being the profit & loss of
+// a trade, taken from the pool. It panics if the zero handling is unknown.
+func (s *Sample) TradeStats(zero ZeroTrades) *TradeStats {
+	t := NewTradeStats(zero)
+	t.AppendMany(s.xs[:s.nb])
+	return t
+}
+
+// Append adds the profit & loss of a trade.
+func (t *TradeStats) Append(pnl float64) {
+	win := pnl > 0
+	if pnl == 0 {
+		switch t.zero {
+		case ZeroTradesExcluded:
+			t.nbExcluded++
+			return
+		case ZeroTradesAsWin:
+			win = true
+		}
+	}
+	if win {
+		if t.nbWins == 0 || pnl > t.largestWin {
+			t.largestWin = pnl
+		}
+		t.nbWins++
+		t.grossProfit += pnl
+	} else {
+		if t.nbLosses == 0 || -pnl > t.largestLoss {
+			t.largestLoss = -pnl
+		}
+		t.nbLosses++
+		t.grossLoss -= pnl
+	}
+}
+
+func (t *TradeStats) AppendMany(pnls []float64) {
+	for _, pnl := range pnls {
+		t.Append(pnl)
+	}
+}
+
+// Merge adds the trades of other to t. It panics with ErrMismatchedTradeStats if they do not handle the zero
+// trades the same way.
+func (t *TradeStats) Merge(other *TradeStats) {
+	if t.zero != other.zero {
+		panic(ErrMismatchedTradeStats)
+	}
+	if other.nbWins > 0 && (t.nbWins == 0 || other.largestWin > t.largestWin) {
+		t.largestWin = other.largestWin
+	}
+	if other.nbLosses > 0 && (t.nbLosses == 0 || other.largestLoss > t.largestLoss) {
+		t.largestLoss = other.largestLoss
+	}
+	t.nbWins += other.nbWins
+	t.nbLosses += other.nbLosses
+	t.nbExcluded += other.nbExcluded
+	t.grossProfit += other.grossProfit
+	t.grossLoss += other.grossLoss
+}
+
+// ZeroTrades returns the way the zero trades are counted.
+func (t *TradeStats) ZeroTrades() ZeroTrades {
+	return t.zero
+}
+
+// Count returns the number of trades, the excluded ones apart.
+func (t *TradeStats) Count() int {
+	return t.nbWins + t.nbLosses
+}
+
+func (t *TradeStats) NbWins() int {
+	return t.nbWins
+}
+
+func (t *TradeStats) NbLosses() int {
+	return t.nbLosses
+}
+
+// NbExcluded returns the number of zero trades ignored with ZeroTradesExcluded.
+func (t *TradeStats) NbExcluded() int {
+	return t.nbExcluded
+}
+
+// GrossProfit returns the sum of the wins.
+func (t *TradeStats) GrossProfit() float64 {
+	return t.grossProfit
+}
+
+// GrossLoss returns the sum of the losses, as a positive value.
+func (t *TradeStats) GrossLoss() float64 {
+	return t.grossLoss
+}
+
+// WinRate returns the fraction of the trades that are wins.
+func (t *TradeStats) WinRate() float64 {
+	if t.Count() == 0 {
+		return math.NaN()
+	}
+	return float64(t.nbWins) / float64(t.Count())
+}
+
+// AverageWin returns the mean profit of the wins.
+func (t *TradeStats) AverageWin() float64 {
+	if t.nbWins == 0 {
+		return math.NaN()
+	}
+	return t.grossProfit / float64(t.nbWins)
+}
+
+// AverageLoss returns the mean loss of the losses, as a positive value.
+func (t *TradeStats) AverageLoss() float64 {
+	if t.nbLosses == 0 {
+		return math.NaN()
+	}
+	return t.grossLoss / float64(t.nbLosses)
+}
+
+// LargestWin returns the largest profit.
+func (t *TradeStats) LargestWin() float64 {
+	if t.nbWins == 0 {
+		return math.NaN()
+	}
+	return t.largestWin
+}
+
+// LargestLoss returns the largest loss, as a positive value.
+func (t *TradeStats) LargestLoss() float64 {
+	if t.nbLosses == 0 {
+		return math.NaN()
+	}
+	return t.largestLoss
+}
+
+// ProfitFactor returns the gross profit divided by the gross loss.
+func (t *TradeStats) ProfitFactor() float64 {
+	return ratio(t.grossProfit, t.grossLoss)
+}
+
+// PayoffRatio returns the average win divided by the average loss.
+func (t *TradeStats) PayoffRatio() float64 {
+	if t.nbWins == 0 || t.nbLosses == 0 {
+		return math.NaN()
+	}
+	return ratio(t.AverageWin(), t.AverageLoss())
+}
+
+// Expectancy returns the mean profit & loss per trade, WinRate * AverageWin - (1 - WinRate) * AverageLoss.
+func (t *TradeStats) Expectancy() float64 {
+	if t.Count() == 0 {
+		return math.NaN()
+	}
+	return (t.grossProfit - t.grossLoss) / float64(t.Count())
+}
+
+// Kelly returns the Kelly fraction W - (1 - W) / R, W being the win rate and R the payoff ratio: the fraction
+// of the capital to risk per trade that maximizes the long-term growth. It is negative when the expectancy is,
+// 1 without losses and -Inf without wins.
+func (t *TradeStats) Kelly() float64 {
+	if t.Count() == 0 {
+		return math.NaN()
+	}
+	if t.nbLosses == 0 {
+		return 1
+	}
+	if t.nbWins == 0 {
+		return math.Inf(-1)
+	}
+	w := t.WinRate()
+	return w - (1-w)/t.PayoffRatio()
+}
+
+// ratio returns a / b, +Inf if b == 0 < a, and NaN if both are zero.
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		if a == 0 {
+			return math.NaN()
+		}
+		return math.Inf(+1)
+	}
+	return a / b
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (t *TradeStats) MarshalBinary() ([]byte, error) {
+	e := binaryEncoder{data: make([]byte, 0, 66)}
+	e.uint8(tradeStatsEncodingVersion)
+	e.uint8(uint8(t.zero))
+	for _, v := range []float64{float64(t.nbWins), float64(t.nbLosses), float64(t.nbExcluded), t.grossProfit,
+		t.grossLoss, t.largestWin, t.largestLoss} {
+		e.float64(v)
+	}
+	return e.data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (t *TradeStats) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return ErrInvalidEncoding
+	}
+	if data[0] != tradeStatsEncodingVersion {
+		return ErrUnsupportedVersion
+	}
+	d := binaryDecoder{data: data[1:]}
+	zero := ZeroTrades(d.uint8())
+	var values [7]float64
+	for i := range values {
+		values[i] = d.float64()
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	if zero < ZeroTradesAsLoss || zero > ZeroTradesExcluded {
+		return ErrInvalidEncoding
+	}
+	t.zero = zero
+	t.nbWins, t.nbLosses, t.nbExcluded = int(values[0]), int(values[1]), int(values[2])
+	t.grossProfit, t.grossLoss, t.largestWin, t.largestLoss = values[3], values[4], values[5], values[6]
+	return nil
+}
@@ -0,0 +1,193 @@
+package gostats
+
+import (
+	"encoding/json"
+	. "github.com/onsi/gomega"
+	"math"
+	"testing"
+)
+
+func TestTradeStats(t *testing.T) {
+
+	pnls := []float64{10, -5, 0, 20, -10, 0, 5}
+
+	t.Run("Zero trades as loss", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ts := NewSampleWithValue(pnls, false).TradeStats(ZeroTradesAsLoss)
+		g.Expect(ts.Count()).To(Equal(7))
+		g.Expect(ts.NbWins()).To(Equal(3))
+		g.Expect(ts.NbLosses()).To(Equal(4))
+		g.Expect(ts.WinRate()).To(Equal(3.0 / 7))
+		g.Expect(ts.AverageWin()).To(Equal(35.0 / 3))
+		g.Expect(ts.AverageLoss()).To(Equal(3.75))
+		g.Expect(ts.ProfitFactor()).To(Equal(35.0 / 15))
+		g.Expect(ts.PayoffRatio()).To(BeNumerically("~", 35.0/3/3.75, 1e-12))
+		g.Expect(ts.Expectancy()).To(BeNumerically("~", 20.0/7, 1e-12))
+		g.Expect(ts.Expectancy()).To(BeNumerically("~", ts.WinRate()*ts.AverageWin()-(1-ts.WinRate())*ts.AverageLoss(), 1e-12))
+		g.Expect(ts.LargestWin()).To(Equal(20.0))
+		g.Expect(ts.LargestLoss()).To(Equal(10.0))
+		g.Expect(ts.Kelly()).To(BeNumerically("~", 3.0/7-4.0/7/ts.PayoffRatio(), 1e-12))
+	})
+
+	t.Run("Zero trades excluded or as win", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleWithValue(pnls, false)
+		ts := s.TradeStats(ZeroTradesExcluded)
+		g.Expect(ts.Count()).To(Equal(5))
+		g.Expect(ts.NbExcluded()).To(Equal(2))
+		g.Expect(ts.AverageLoss()).To(Equal(7.5))
+		g.Expect(ts.Expectancy()).To(Equal(4.0))
+
+		ts = s.TradeStats(ZeroTradesAsWin)
+		g.Expect(ts.NbWins()).To(Equal(5))
+		g.Expect(ts.AverageWin()).To(Equal(7.0))
+		g.Expect(ts.LargestWin()).To(Equal(20.0))
+		g.Expect(ts.GrossLoss()).To(Equal(15.0))
+	})
+
+	t.Run("Edge cases", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ts := NewTradeStats(ZeroTradesExcluded)
+		g.Expect(math.IsNaN(ts.WinRate())).To(BeTrue())
+		g.Expect(math.IsNaN(ts.Expectancy())).To(BeTrue())
+		g.Expect(math.IsNaN(ts.ProfitFactor())).To(BeTrue())
+		g.Expect(math.IsNaN(ts.Kelly())).To(BeTrue())
+
+		ts.AppendMany([]float64{1, 2})
+		g.Expect(math.IsInf(ts.ProfitFactor(), 1)).To(BeTrue())
+		g.Expect(math.IsNaN(ts.AverageLoss())).To(BeTrue())
+		g.Expect(math.IsNaN(ts.LargestLoss())).To(BeTrue())
+		g.Expect(ts.Kelly()).To(Equal(1.0))
+
+		ts = NewTradeStats(ZeroTradesAsLoss)
+		ts.AppendMany([]float64{-1, -3})
+		g.Expect(ts.ProfitFactor()).To(Equal(0.0))
+		g.Expect(ts.LargestLoss()).To(Equal(3.0))
+		g.Expect(math.IsInf(ts.Kelly(), -1)).To(BeTrue())
+
+		g.Expect(func() { NewTradeStats(ZeroTrades(5)) }).To(Panic())
+		g.Expect(func() { NewTradeStats(ZeroTradesAsWin).Merge(ts) }).To(Panic())
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		a, b := NewTradeStats(ZeroTradesAsLoss), NewTradeStats(ZeroTradesAsLoss)
+		a.AppendMany(pnls[:3])
+		b.AppendMany(pnls[3:])
+		a.Merge(b)
+		g.Expect(a).To(Equal(NewSampleWithValue(pnls, false).TradeStats(ZeroTradesAsLoss)))
+		g.Expect(func() { a.Merge(NewTradeStats(ZeroTradesAsWin)) }).To(PanicWith(ErrMismatchedTradeStats))
+		b.CleanPool()
+	})
+
+	t.Run("Pool", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ts := NewTradeStats(ZeroTradesAsWin)
+		ts.AppendMany(pnls)
+		clone := ts.Clone()
+		g.Expect(clone).To(Equal(ts))
+		g.Expect(clone).NotTo(BeIdenticalTo(ts))
+		ts.CleanPool()
+		clone.Append(1)
+		g.Expect(clone.Count()).To(Equal(len(pnls) + 1))
+		clone.CleanPool()
+
+		// Taken back from the pool, a TradeStats is empty.
+		ts = NewTradeStats(ZeroTradesExcluded)
+		g.Expect(ts.Count()).To(Equal(0))
+		g.Expect(ts.NbExcluded()).To(Equal(0))
+		g.Expect(ts.ZeroTrades()).To(Equal(ZeroTradesExcluded))
+		g.Expect(math.IsNaN(ts.LargestWin())).To(BeTrue())
+		ts.CleanPool()
+
+		s := NewSampleStreamWithOptions(SampleStreamOptions{Quantiles: DefaultTDigestCompression, TradeStats: true})
+		s.AppendMany(pnls)
+		g.Expect(s.TradeStats().ZeroTrades()).To(Equal(ZeroTradesAsLoss))
+		g.Expect(s.TradeStats().Count()).To(Equal(len(pnls)))
+		g.Expect(s.Quantile(1)).To(Equal(20.0))
+		s.CleanPool()
+		g.Expect(NewSampleStreamWithOptions(SampleStreamOptions{}).TradeStats()).To(BeNil())
+		g.Expect(func() { NewSampleStreamWithOptions(SampleStreamOptions{Quantiles: 0.5}) }).To(Panic())
+	})
+
+	t.Run("SampleStream", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		expected := NewSampleWithValue(pnls, false).TradeStats(ZeroTradesExcluded)
+
+		s := NewSampleStreamWithTradeStats(ZeroTradesExcluded)
+		s.AppendMany(pnls)
+		g.Expect(s.TradeStats()).To(Equal(expected))
+
+		a := NewSampleStreamWithTradeStats(ZeroTradesExcluded)
+		a.AppendMany(pnls[:4])
+		b := NewSampleStreamWithTradeStats(ZeroTradesExcluded)
+		b.AppendMany(pnls[4:])
+		merged := MergeSampleStreams(a, b)
+		g.Expect(merged.TradeStats()).To(Equal(expected))
+		g.Expect(a.TradeStats().Count()).To(Equal(3))
+
+		empty := NewSampleStreamWithTradeStats(ZeroTradesExcluded)
+		empty.Merge(s)
+		g.Expect(empty.TradeStats()).To(Equal(expected))
+		g.Expect(empty.TradeStats()).NotTo(BeIdenticalTo(s.TradeStats()))
+
+		g.Expect(NewSampleStream().TradeStats()).To(BeNil())
+	})
+
+	t.Run("Encoding", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewSampleStreamWithTradeStats(ZeroTradesAsWin)
+		s.AppendMany(pnls)
+
+		data, err := s.MarshalBinary()
+		g.Expect(err).NotTo(HaveOccurred())
+		decoded := NewSampleStream()
+		g.Expect(decoded.UnmarshalBinary(data)).To(Succeed())
+		g.Expect(decoded.TradeStats()).To(Equal(s.TradeStats()))
+
+		data, err = json.Marshal(s)
+		g.Expect(err).NotTo(HaveOccurred())
+		decoded = NewSampleStream()
+		g.Expect(json.Unmarshal(data, decoded)).To(Succeed())
+		g.Expect(decoded.TradeStats()).To(Equal(s.TradeStats()))
+
+		// Version 1 has no trade statistics, and is still read.
+		plain := NewSampleStream()
+		plain.AppendMany(pnls)
+		data, _ = plain.MarshalBinary()
+		data[0] = 1
+		g.Expect(decoded.UnmarshalBinary(data)).To(Succeed())
+		g.Expect(decoded.TradeStats()).To(BeNil())
+		g.Expect(decoded.Len()).To(Equal(len(pnls)))
+		data, _ = s.MarshalBinary()
+		data[0] = 1
+		g.Expect(decoded.UnmarshalBinary(data)).To(Equal(ErrInvalidEncoding))
+		g.Expect(json.Unmarshal([]byte(`{"version":1,"tradeStats":"AQ=="}`), decoded)).To(Equal(ErrInvalidEncoding))
+
+		// A corrupt section leaves the stream unchanged.
+		both := NewSampleStreamWithOptions(SampleStreamOptions{
+			Quantiles:  DefaultTDigestCompression,
+			TradeStats: true,
+			ZeroTrades: ZeroTradesAsWin,
+		})
+		both.AppendMany(pnls)
+		data, _ = both.MarshalBinary()
+		trades, _ := both.TradeStats().MarshalBinary()
+		data[len(data)-len(trades)] = 9
+		decoded = NewSampleStreamWithQuantiles(DefaultTDigestCompression)
+		decoded.Append(1)
+		digest := decoded.Digest()
+		g.Expect(decoded.UnmarshalBinary(data)).To(Equal(ErrUnsupportedVersion))
+		g.Expect(decoded.Len()).To(Equal(1))
+		g.Expect(decoded.Digest()).To(BeIdenticalTo(digest))
+		g.Expect(decoded.Quantile(0.5)).To(Equal(1.0))
+		g.Expect(decoded.TradeStats()).To(BeNil())
+
+		data, _ = s.TradeStats().MarshalBinary()
+		data[0] = 2
+		g.Expect(new(TradeStats).UnmarshalBinary(data)).To(Equal(ErrUnsupportedVersion))
+		g.Expect(new(TradeStats).UnmarshalBinary(data[:5])).To(Equal(ErrUnsupportedVersion))
+		data[0] = 1
+		g.Expect(new(TradeStats).UnmarshalBinary(data[:5])).To(Equal(ErrInvalidEncoding))
+	})
+}
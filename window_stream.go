@@ -0,0 +1,246 @@
+package gostats
+
+import (
+	"math"
+	"sync"
+)
+
+var WindowStreamPool = sync.Pool{
+	New: func() interface{} { return new(WindowStream) },
+}
+
+// WindowStream is a SampleStream restricted to the last `size` appended values: once the window is full,
+// every Append evicts the oldest value. Mean & Variance are updated in O(1) on eviction, and Bounds are
+// kept by two monotonic deques, so every Append is O(1) amortized and does not allocate.
+//
+// To prevent rounding errors from accumulating through evictions, the sums are recomputed from the window
+// once every `size` evictions.
+type WindowStream struct {
+	// values is the ring buffer of the window, values[head] being the oldest value.
+	values []float64
+	head   int
+	count  int
+	// internal values
+	moments slidingMoments
+	mins    monotonicDeque
+	maxs    monotonicDeque
+	// streaming
+	_seq         int
+	_sinceReSync int
+}
+
+// NewWindowStream returns a WindowStream over the last size values. It panics if size < 1.
+func NewWindowStream(size int) *WindowStream {
+	if size < 1 {
+		panic("WindowStream size must be positive")
+	}
+	s := WindowStreamPool.Get().(*WindowStream)
+	s.initEmptyValues(size)
+	return s
+}
+
+func (s *WindowStream) CleanPool() {
+	WindowStreamPool.Put(s)
+}
+
+func (s *WindowStream) initEmptyValues(size int) {
+	if cap(s.values) >= size {
+		s.values = s.values[:size]
+	} else {
+		s.values = make([]float64, size)
+	}
+	s.head = 0
+	s.count = 0
+	s.moments = slidingMoments{}
+	s.mins.init(size, func(a, b float64) bool { return a <= b })
+	s.maxs.init(size, func(a, b float64) bool { return a >= b })
+	s._seq = 0
+	s._sinceReSync = 0
+}
+
+func (s *WindowStream) AppendMany(values []float64) {
+	for _, value := range values {
+		s.Append(value)
+	}
+}
+
+func (s *WindowStream) Append(value float64) {
+	size := len(s.values)
+	if s.count == size {
+		s.evict()
+	}
+
+	s.values[(s.head+s.count)%size] = value
+	s.count++
+	s.moments.add(value)
+
+	s._seq++
+	s.mins.expire(s._seq - size)
+	s.maxs.expire(s._seq - size)
+	s.mins.push(s._seq, value)
+	s.maxs.push(s._seq, value)
+}
+
+// evict removes the oldest value of a full window.
+func (s *WindowStream) evict() {
+	size := len(s.values)
+	old := s.values[s.head]
+	s.head = (s.head + 1) % size
+	s.count--
+
+	s._sinceReSync++
+	if s._sinceReSync >= size {
+		s.reSync()
+		return
+	}
+
+	s.moments.remove(old)
+}
+
+// reSync recomputes the sums from the values of the window.
+func (s *WindowStream) reSync() {
+	s._sinceReSync = 0
+	s.moments = slidingMoments{}
+	size := len(s.values)
+	for i := 0; i < s.count; i++ {
+		s.moments.add(s.values[(s.head+i)%size])
+	}
+}
+
+// Len returns the number of values in the window.
+func (s *WindowStream) Len() int {
+	return s.count
+}
+
+// Size returns the maximum number of values in the window.
+func (s *WindowStream) Size() int {
+	return len(s.values)
+}
+
+// Full reports whether the window holds Size values.
+func (s *WindowStream) Full() bool {
+	return s.count == len(s.values)
+}
+
+// Values appends the values of the window to dst, from the oldest to the newest, and returns the result.
+func (s *WindowStream) Values(dst []float64) []float64 {
+	size := len(s.values)
+	for i := 0; i < s.count; i++ {
+		dst = append(dst, s.values[(s.head+i)%size])
+	}
+	return dst
+}
+
+// Bounds returns the minimum and maximum values of the window, or NaN if it is empty.
+func (s *WindowStream) Bounds() (min float64, max float64) {
+	if s.count == 0 {
+		return math.NaN(), math.NaN()
+	}
+	return s.mins.front(), s.maxs.front()
+}
+
+// Sum returns the sum of the window.
+func (s *WindowStream) Sum() float64 {
+	return s.moments.sum
+}
+
+// Mean returns the arithmetic mean of the window.
+func (s *WindowStream) Mean() float64 {
+	return s.moments.mean()
+}
+
+func (s *WindowStream) Variance() float64 {
+	return s.moments.variance()
+}
+
+// StdDev returns the sample standard deviation of the window.
+func (s *WindowStream) StdDev() float64 {
+	return math.Pow(s.Variance(), 0.5)
+}
+
+// slidingMoments maintains the sum, mean and sum of squared deviations of a set of values, with Welford's
+// update when a value is added, and its reverse when a value is removed.
+type slidingMoments struct {
+	count float64
+	sum   float64
+	_mean float64
+	_m2   float64
+}
+
+func (m *slidingMoments) add(value float64) {
+	m.count++
+	m.sum = m.sum + value
+	delta := value - m._mean
+	m._mean = m._mean + delta/m.count
+	m._m2 = m._m2 + delta*(value-m._mean)
+}
+
+func (m *slidingMoments) remove(value float64) {
+	m.count--
+	m.sum = m.sum - value
+	if m.count == 0 {
+		*m = slidingMoments{}
+		return
+	}
+	mean := m._mean + (m._mean-value)/m.count
+	m._m2 = m._m2 - (value-m._mean)*(value-mean)
+	if m._m2 < 0 {
+		m._m2 = 0
+	}
+	m._mean = mean
+}
+
+func (m *slidingMoments) mean() float64 {
+	return m.sum / m.count
+}
+
+func (m *slidingMoments) variance() float64 {
+	return m._m2 / (m.count - 1)
+}
+
+// monotonicDeque is a ring buffer deque of (sequence, value) pairs whose values are monotonic according to
+// keep: keep(a, b) is true when a, older, must be kept after appending b. Its front is then the minimum (or
+// maximum) of the window.
+type monotonicDeque struct {
+	seqs   []int
+	values []float64
+	head   int
+	len    int
+	keep   func(a, b float64) bool
+}
+
+func (d *monotonicDeque) init(size int, keep func(a, b float64) bool) {
+	if cap(d.seqs) >= size {
+		d.seqs = d.seqs[:size]
+		d.values = d.values[:size]
+	} else {
+		d.seqs = make([]int, size)
+		d.values = make([]float64, size)
+	}
+	d.head = 0
+	d.len = 0
+	d.keep = keep
+}
+
+func (d *monotonicDeque) push(seq int, value float64) {
+	size := len(d.seqs)
+	for d.len > 0 && !d.keep(d.values[(d.head+d.len-1)%size], value) {
+		d.len--
+	}
+	i := (d.head + d.len) % size
+	d.seqs[i] = seq
+	d.values[i] = value
+	d.len++
+}
+
+// expire removes the values whose sequence is <= seq.
+func (d *monotonicDeque) expire(seq int) {
+	for d.len > 0 && d.seqs[d.head] <= seq {
+		d.head = (d.head + 1) % len(d.seqs)
+		d.len--
+	}
+}
+
+func (d *monotonicDeque) front() float64 {
+	return d.values[d.head]
+}
@@ -0,0 +1,96 @@
+package gostats
+
+import (
+	. "github.com/onsi/gomega"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestWindowStream(t *testing.T) {
+
+	t.Run("Eviction", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewWindowStream(3)
+		g.Expect(math.IsNaN(s.Mean())).To(BeTrue())
+		min, max := s.Bounds()
+		g.Expect(math.IsNaN(min) && math.IsNaN(max)).To(BeTrue())
+
+		s.AppendMany([]float64{5, 1, 3})
+		g.Expect(s.Full()).To(BeTrue())
+		g.Expect(s.Mean()).To(Equal(3.0))
+		g.Expect(s.Variance()).To(Equal(4.0))
+		min, max = s.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{1, 5}))
+
+		s.Append(2)
+		g.Expect(s.Len()).To(Equal(3))
+		g.Expect(s.Values(nil)).To(Equal([]float64{1, 3, 2}))
+		g.Expect(s.Sum()).To(Equal(6.0))
+		g.Expect(s.Variance()).To(BeNumerically("~", 1, 1e-12))
+		min, max = s.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{1, 3}))
+
+		s.Append(4)
+		s.Append(4)
+		min, max = s.Bounds()
+		g.Expect([]float64{min, max}).To(Equal([]float64{2, 4}))
+		s.CleanPool()
+	})
+
+	t.Run("Against Sample", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		r := rand.New(rand.NewSource(1))
+		size := 17
+		s := NewWindowStream(size)
+		all := make([]float64, 0)
+		for i := 0; i < 1000; i++ {
+			v := r.NormFloat64()*10 + 1000
+			s.Append(v)
+			all = append(all, v)
+			if len(all) < size {
+				continue
+			}
+			sample := NewSampleWithValue(all[len(all)-size:], false)
+			g.Expect(s.Mean()).To(BeNumerically("~", sample.Mean(), 1e-9))
+			g.Expect(s.StdDev()).To(BeNumerically("~", sample.StdDev(), 1e-9))
+			min, max := s.Bounds()
+			smin, smax := sample.Bounds()
+			g.Expect(min).To(Equal(smin))
+			g.Expect(max).To(Equal(smax))
+		}
+		s.CleanPool()
+	})
+
+	t.Run("Pool reuse", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		s := NewWindowStream(10)
+		s.AppendMany([]float64{1, 2, 3})
+		s.CleanPool()
+		s = NewWindowStream(2)
+		g.Expect(s.Len()).To(Equal(0))
+		g.Expect(s.Size()).To(Equal(2))
+		s.AppendMany([]float64{1, 2, 3})
+		g.Expect(s.Values(nil)).To(Equal([]float64{2, 3}))
+		g.Expect(func() { NewWindowStream(0) }).To(Panic())
+		s.CleanPool()
+	})
+
+}
+
+func BenchmarkWindowStream(b *testing.B) {
+	points := make([]float64, 300)
+	for i := range points {
+		points[i] = float64(i % 37)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := NewWindowStream(50)
+		s.AppendMany(points)
+		s.Mean()
+		s.StdDev()
+		s.Bounds()
+		s.CleanPool()
+	}
+}